@@ -0,0 +1,273 @@
+package gogetvers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Markdown output format for Changelog; the default.  FormatJSON is also supported.
+const FormatMarkdown = "markdown"
+
+// ChangelogCommit is a commit in a changelog.
+type ChangelogCommit struct {
+	Hash    string
+	Subject string
+}
+
+// ChangelogDependency is a dependency that changed between two releases.
+type ChangelogDependency struct {
+	Name     string
+	Change   string // One of "added", "removed" or "updated".
+	From     string `json:",omitempty"` // Describe in the old manifest.
+	To       string `json:",omitempty"` // Describe in the new manifest.
+	FromHash string `json:",omitempty"`
+	ToHash   string `json:",omitempty"`
+	Commits  []ChangelogCommit
+	Error    string `json:",omitempty"` // Why Commits could not be listed; empty if they were.
+}
+
+// Changelog is the changes to a package and its dependencies between two
+// revisions, usually release tags.
+type Changelog struct {
+	From         string
+	To           string
+	Name         string `json:",omitempty"` // The package's git.
+	Commits      []ChangelogCommit
+	Dependencies []*ChangelogDependency
+	Error        string `json:",omitempty"` // Why Dependencies could not be found; empty if they were.
+}
+
+// Returns the commits in revisions of the git at dir.
+func getChangelogCommits(dir, revisions string, runner Runner) ([]ChangelogCommit, error) {
+	gitlog := NewCommandGitLog(revisions)
+	err := runner.Run(gitlog, dir)
+	if err != nil {
+		return nil, err
+	}
+	rv := []ChangelogCommit{}
+	for _, line := range strings.Split(gitlog.Output, "\n") {
+		if line == "" {
+			continue
+		}
+		pieces := strings.SplitN(line, "\t", 2)
+		commit := ChangelogCommit{Hash: pieces[0]}
+		if len(pieces) == 2 {
+			commit.Subject = pieces[1]
+		}
+		rv = append(rv, commit)
+	}
+	return rv, nil
+}
+
+// Returns the manifest File as of ref.
+func (g *GoGetVers) showManifest(ref string) (*PackageInfo, error) {
+	rel, err := filepath.Rel(g.Path, g.File)
+	if err != nil {
+		return nil, err
+	}
+	gitshow := NewCommandGitShow(ref, "./"+filepath.ToSlash(rel))
+	err = g.runner().Run(gitshow, g.Path)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("no manifest at %v: %v", ref, err))
+	}
+	rv := &PackageInfo{}
+	err = json.Unmarshal([]byte(gitshow.Output), rv)
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// Returns the manifest that describes the commit ref, e.g. a release tag.
+// Release tags the commit before the one with its manifest unless the
+// release was made with CommitFirst, so the manifest committed at ref, if
+// any, is the previous release's.  Then the manifest of the child of ref
+// that describes ref is used; if there is none the manifest at ref is used
+// with a warning.
+func (g *GoGetVers) refManifest(ref string) (*PackageInfo, error) {
+	gitrevparse := NewCommandGitRevParseCommit(ref)
+	err := g.runner().Run(gitrevparse, g.Path)
+	if err != nil {
+		return nil, err
+	}
+	hash := gitrevparse.Output
+	rv, showErr := g.showManifest(ref)
+	// A CommitFirst manifest names the release by its tag alone.
+	if showErr == nil && (rv.Git == nil || rv.Git.Hash == "" || rv.Git.Hash == hash) {
+		return rv, nil
+	}
+	gitrevlist := NewCommandGitRevListDescendants(hash)
+	err = g.runner().Run(gitrevlist, g.Path)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(gitrevlist.Output, "\n") {
+		commits := strings.Fields(line)
+		if len(commits) < 2 || !inStrings(hash, commits[1:]) {
+			continue
+		}
+		child, err := g.showManifest(commits[0])
+		if err == nil && child.Git != nil && child.Git.Hash == hash {
+			g.Status.Printf("Using the manifest of %v, the release commit after %v\n", shortHash(commits[0]), ref)
+			return child, nil
+		}
+	}
+	if showErr != nil {
+		return nil, showErr
+	}
+	g.Status.Warning(fmt.Sprintf("no manifest describes %v; using the one committed at %v, which describes %v", ref, ref, shortHash(rv.Git.Hash)))
+	return rv, nil
+}
+
+// Returns true if str is in strs.
+func inStrings(str string, strs []string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the changes between the revisions from and to, e.g. two release
+// tags: the commits of the package's git and, from the manifests committed
+// for from and to, the dependencies that moved and their commits.  The
+// dependencies must be on disk where the manifest places them relative to
+// the package.
+func (g *GoGetVers) Changelog(from, to string) (*Changelog, error) {
+	if g == nil {
+		return nil, errors.New("nil receiver")
+	}
+	if from == "" || to == "" {
+		return nil, errors.New("two revisions are required")
+	}
+	g.Status.Printf("Creating changelog from %v to %v\n", from, to)
+	rv := &Changelog{From: from, To: to, Dependencies: []*ChangelogDependency{}}
+	var err error
+	rv.Commits, err = getChangelogCommits(g.Path, from+".."+to, g.runner())
+	if err != nil {
+		g.Status.Error(err)
+		return nil, err
+	}
+	// Dependency changes need both manifests.
+	oldInfo, err := g.refManifest(from)
+	if err != nil {
+		g.Status.Warning(err.Error())
+		rv.Error = err.Error()
+		return rv, nil
+	}
+	newInfo, err := g.refManifest(to)
+	if err != nil {
+		g.Status.Warning(err.Error())
+		rv.Error = err.Error()
+		return rv, nil
+	}
+	if newInfo.Git != nil {
+		rv.Name = newInfo.Git.HomeDir
+	}
+	// Manifest paths are relative to the directory that contains everything.
+	rootDir := strings.TrimSuffix(filepath.Clean(g.Path), filepath.FromSlash(newInfo.PackageDir))
+	olds := make(map[string]*Git)
+	for _, git := range oldInfo.getGits() {
+		olds[git.HomeDir] = git
+	}
+	news := make(map[string]*Git)
+	for _, git := range newInfo.getGits() {
+		news[git.HomeDir] = git
+		if git.HomeDir == rv.Name {
+			continue
+		}
+		old, ok := olds[git.HomeDir]
+		if !ok {
+			rv.Dependencies = append(rv.Dependencies, &ChangelogDependency{Name: git.HomeDir, Change: "added", To: git.Describe, ToHash: git.Hash, Commits: []ChangelogCommit{}})
+			continue
+		}
+		if old.Hash == git.Hash {
+			continue
+		}
+		dep := &ChangelogDependency{Name: git.HomeDir, Change: "updated", From: old.Describe, To: git.Describe, FromHash: old.Hash, ToHash: git.Hash, Commits: []ChangelogCommit{}}
+		commits, err := getChangelogCommits(filepath.Join(rootDir, filepath.FromSlash(git.HomeDir)), old.Hash+".."+git.Hash, g.runner())
+		if err != nil {
+			g.Status.Warning(fmt.Sprintf("could not list commits of %v: %v", git.HomeDir, err))
+			dep.Error = err.Error()
+		} else {
+			dep.Commits = commits
+		}
+		rv.Dependencies = append(rv.Dependencies, dep)
+	}
+	for _, git := range oldInfo.getGits() {
+		if _, ok := news[git.HomeDir]; !ok && git.HomeDir != rv.Name {
+			rv.Dependencies = append(rv.Dependencies, &ChangelogDependency{Name: git.HomeDir, Change: "removed", From: git.Describe, FromHash: git.Hash, Commits: []ChangelogCommit{}})
+		}
+	}
+	return rv, nil
+}
+
+// Returns the first 8 characters of hash.
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
+
+// Returns the changelog as a Markdown document.
+func (c *Changelog) Markdown() string {
+	if c == nil {
+		return ""
+	}
+	rv := fmt.Sprintf("# Changes from %v to %v\n", c.From, c.To)
+	if c.Name != "" {
+		rv = rv + "\n## " + c.Name + "\n"
+	}
+	rv = rv + "\n"
+	if len(c.Commits) == 0 {
+		rv = rv + "No commits.\n"
+	}
+	for _, commit := range c.Commits {
+		rv = rv + "- " + shortHash(commit.Hash) + " " + commit.Subject + "\n"
+	}
+	if c.Error != "" {
+		rv = rv + "\nDependency changes are not known; " + c.Error + ".\n"
+		return rv
+	}
+	rv = rv + "\n## Dependencies\n\n"
+	if len(c.Dependencies) == 0 {
+		rv = rv + "No dependencies changed.\n"
+	}
+	for _, dep := range c.Dependencies {
+		switch dep.Change {
+		case "added":
+			rv = rv + fmt.Sprintf("### %v (added at %v)\n", dep.Name, dep.To)
+		case "removed":
+			rv = rv + fmt.Sprintf("### %v (removed; was %v)\n", dep.Name, dep.From)
+		default:
+			rv = rv + fmt.Sprintf("### %v %v → %v\n", dep.Name, dep.From, dep.To)
+		}
+		if dep.Error != "" {
+			rv = rv + "\nCommits are not known; " + dep.Error + ".\n"
+		} else if len(dep.Commits) > 0 {
+			rv = rv + "\n"
+		}
+		for _, commit := range dep.Commits {
+			rv = rv + "- " + shortHash(commit.Hash) + " " + commit.Subject + "\n"
+		}
+		rv = rv + "\n"
+	}
+	return strings.TrimRight(rv, "\n") + "\n"
+}
+
+// Returns the changelog in format, FormatMarkdown or FormatJSON; the
+// default is FormatMarkdown.
+func (c *Changelog) Format(format string) (string, error) {
+	switch format {
+	case "", FormatMarkdown:
+		return c.Markdown(), nil
+	case FormatJSON:
+		return jsonString(c)
+	}
+	return "", errors.New(fmt.Sprintf("unknown format: %v", format))
+}
@@ -0,0 +1,137 @@
+package gogetvers
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetChangelogCommits(t *testing.T) {
+	fake := NewFakeRunner()
+	fake.Script(fakePackage, "git log --format=%H%x09%s 1.0.0..1.1.0", "aaaa\tAdd a\tb\n\nbbbb\ncccc\tFix c\n", 0)
+	commits, err := getChangelogCommits(fakePackage, "1.0.0..1.1.0", fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ChangelogCommit{{"aaaa", "Add a\tb"}, {"bbbb", ""}, {"cccc", "Fix c"}}
+	if len(commits) != len(want) {
+		t.Fatalf("commits %v; want %v", commits, want)
+	}
+	for k := range want {
+		if commits[k] != want[k] {
+			t.Errorf("commit %v is %v; want %v", k, commits[k], want[k])
+		}
+	}
+	fake.Script(fakePackage, "git log --format=%H%x09%s 1.0.0..nope", "", 128)
+	if _, err = getChangelogCommits(fakePackage, "1.0.0..nope", fake); err == nil {
+		t.Error("failing git log is not an error")
+	}
+}
+
+// Returns the manifest of example.com/proj with the dependency gits deps,
+// each HomeDir followed by its hash, as JSON.
+func changelogManifest(t *testing.T, deps ...string) string {
+	info := NewPackageInfo("example.com/proj", "")
+	info.Git = &Git{HomeDir: "example.com/proj", Hash: fakeHash, Describe: "1.0.0-0-g0123abcd"}
+	for k := 0; k+1 < len(deps); k += 2 {
+		git := &Git{HomeDir: deps[k], Hash: deps[k+1], Describe: deps[k+1][:8]}
+		info.DepsGit = append(info.DepsGit, &GitDependency{Name: deps[k], Git: git})
+	}
+	rv, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(rv)
+}
+
+func TestChangelog(t *testing.T) {
+	g, fake := newFakeGoGetVers(t)
+	g.File = filepath.Join(fakePackage, "gogetvers.manifest")
+	fake.Script(fakePackage, "git log --format=%H%x09%s 1.0.0..1.1.0", "aaaa\tRelease 1.1.0", 0)
+	// The manifests describe the tagged commits, as with CommitFirst.
+	fake.Script(fakePackage, "git rev-parse 1.0.0^{commit}", fakeHash, 0)
+	fake.Script(fakePackage, "git rev-parse 1.1.0^{commit}", fakeHash, 0)
+	fake.Script(fakePackage, "git show 1.0.0:./gogetvers.manifest", changelogManifest(t, "example.com/dep", fakeHash, "example.com/old", fakeHash), 0)
+	fake.Script(fakePackage, "git show 1.1.0:./gogetvers.manifest", changelogManifest(t, "example.com/dep", fakeDepHash, "example.com/new", fakeHash), 0)
+	fake.Script(fakeDep, "git log --format=%H%x09%s "+fakeHash+".."+fakeDepHash, "bbbb\tFix dep", 0)
+	log, err := g.Changelog("1.0.0", "1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.Error != "" || log.Name != "example.com/proj" || len(log.Commits) != 1 || log.Commits[0].Subject != "Release 1.1.0" {
+		t.Fatalf("changelog %+v", log)
+	}
+	changes := make(map[string]*ChangelogDependency)
+	for _, dep := range log.Dependencies {
+		changes[dep.Name] = dep
+	}
+	if dep := changes["example.com/dep"]; dep == nil || dep.Change != "updated" || len(dep.Commits) != 1 || dep.Commits[0].Hash != "bbbb" {
+		t.Errorf("updated dependency %+v", dep)
+	}
+	if dep := changes["example.com/new"]; dep == nil || dep.Change != "added" {
+		t.Errorf("added dependency %+v", dep)
+	}
+	if dep := changes["example.com/old"]; dep == nil || dep.Change != "removed" {
+		t.Errorf("removed dependency %+v", dep)
+	}
+	if len(changes) != 3 {
+		t.Errorf("dependencies %v", changes)
+	}
+}
+
+func TestChangelogWithoutManifest(t *testing.T) {
+	g, fake := newFakeGoGetVers(t)
+	g.File = filepath.Join(fakePackage, "gogetvers.manifest")
+	fake.Script(fakePackage, "git log --format=%H%x09%s 1.0.0..1.1.0", "aaaa\tRelease 1.1.0", 0)
+	fake.Script(fakePackage, "git show 1.0.0:./gogetvers.manifest", "", 128)
+	fake.Script(fakePackage, "git rev-parse 1.0.0^{commit}", fakeHash, 0)
+	fake.Script(fakePackage, "git rev-list --ancestry-path --reverse --parents ^"+fakeHash+" --all", "", 0)
+	log, err := g.Changelog("1.0.0", "1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(log.Error, "no manifest at 1.0.0") || len(log.Commits) != 1 || len(log.Dependencies) != 0 {
+		t.Errorf("changelog without the old manifest %+v", log)
+	}
+}
+
+// Commits an empty change with subject in the git at dir.
+func commitGit(t *testing.T, dir, subject string) {
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", subject)
+}
+
+func TestChangelogOfDefaultOrderReleases(t *testing.T) {
+	dir := newGitPackage(t)
+	dep := filepath.Join(filepath.Dir(dir), "dep")
+	if err := Mkdir(dep, 0770); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dep, "init", "-q")
+	runGit(t, dep, "config", "user.name", "Test")
+	runGit(t, dep, "config", "user.email", "test@example.com")
+	runGit(t, dep, "config", "commit.gpgsign", "false")
+	commitGit(t, dep, "dep start")
+	g := newGitGoGetVers(t, dir)
+	g.Runner.(*goFakeRunner).Fake.Script(dir, "go list -f {{.Deps}}", "[example.com/dep fmt]", 0)
+	gofile := filepath.Join(dir, "generated_gogetvers.go")
+	commitGit(t, dep, "dep change one")
+	if err := g.Release(gofile, "proj", "v1.3.0", ""); err != nil {
+		t.Fatal(err)
+	}
+	commitGit(t, dep, "dep change two")
+	if err := g.Release(gofile, "proj", "v1.4.0", ""); err != nil {
+		t.Fatal(err)
+	}
+	log, err := g.Changelog("v1.3.0", "v1.4.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.Error != "" || len(log.Dependencies) != 1 {
+		t.Fatalf("changelog %+v", log)
+	}
+	commits := log.Dependencies[0].Commits
+	if len(commits) != 1 || commits[0].Subject != "dep change two" {
+		t.Errorf("dependency commits %+v; want dep change two", commits)
+	}
+}
@@ -6,6 +6,9 @@ import (
 	gv "gogetvers"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var (
@@ -16,9 +19,47 @@ type options struct {
 	path  string
 	file  string
 	dashg string
+	dashi string
 	dashm string
 	dashn string
+	dashp string
 	dasht string
+	dashT string
+	trace string
+	//
+	format   string
+	varName  string
+	typeName string
+	build    string
+	//
+	config string
+	gitbin string
+	gobin  string
+	env    []string
+	//
+	retries    string
+	retryDelay string
+	//
+	dryrun bool
+	http   bool
+	embed  bool
+	//
+	commitFirst   bool
+	requireTagged bool
+	allowUntagged []string
+	remote        string
+	pushBranch    bool
+	checkUpstream bool
+	verify        bool
+	verifyTag     bool
+	verifyCmds    []string
+	force         bool
+	push          bool
+	//
+	binary string
+	bump   string
+	from   string
+	to     string
 }
 
 func main() {
@@ -44,7 +85,7 @@ func main() {
 	case "-h", "--help":
 		args = args[1:]
 		dousage()
-	case "checkout", "generate", "make", "print", "rebuild", "release", "tag":
+	case "changelog", "checkout", "extract", "generate", "init", "inspect", "ldflags", "make", "print", "rebuild", "release", "tag":
 		sub := args[0]
 		args = args[1:]
 		// Options parsing...
@@ -54,11 +95,28 @@ func main() {
 				flag   string
 				target *string
 			}{
+				{"-c", &opts.config},
 				{"-f", &opts.file},
 				{"-g", &opts.dashg},
+				{"-i", &opts.dashi},
 				{"-m", &opts.dashm},
 				{"-n", &opts.dashn},
-				{"-t", &opts.dasht}}
+				{"-p", &opts.dashp},
+				{"-t", &opts.dasht},
+				{"-T", &opts.dashT},
+				{"--trace", &opts.trace},
+				{"--format", &opts.format},
+				{"--var", &opts.varName},
+				{"--type", &opts.typeName},
+				{"--build", &opts.build},
+				{"--bump", &opts.bump},
+				{"--from", &opts.from},
+				{"--to", &opts.to},
+				{"--remote", &opts.remote},
+				{"--git", &opts.gitbin},
+				{"--go", &opts.gobin},
+				{"--retries", &opts.retries},
+				{"--retry-delay", &opts.retryDelay}}
 			for _, opt := range tempopts {
 				if len(args) > 0 && args[0] == opt.flag {
 					if len(args) >= 2 {
@@ -72,17 +130,79 @@ func main() {
 					args = args[1:]
 				}
 			}
-			//
-			if len(args) == 1 {
-				opts.path = args[0]
-				args = args[1:]
+			listopts := []struct {
+				flag   string
+				target *[]string
+			}{
+				{"--env", &opts.env},
+				{"--allow-untagged", &opts.allowUntagged},
+				{"--verify-cmd", &opts.verifyCmds}}
+			for _, opt := range listopts {
+				if len(args) > 0 && args[0] == opt.flag {
+					if len(args) >= 2 {
+						*opt.target = append(*opt.target, args[1])
+						args = args[1:]
+					} else {
+						fmt.Printf("Error: Missing value for %v\n", opt.flag)
+						exitCode = 1
+						return
+					}
+					args = args[1:]
+				}
+			}
+			boolopts := []struct {
+				flag   string
+				target *bool
+			}{
+				{"--dry-run", &opts.dryrun},
+				{"--http", &opts.http},
+				{"--embed", &opts.embed},
+				{"--commit-first", &opts.commitFirst},
+				{"--require-tagged", &opts.requireTagged},
+				{"--push-branch", &opts.pushBranch},
+				{"--check-upstream", &opts.checkUpstream},
+				{"--verify", &opts.verify},
+				{"--verify-tag", &opts.verifyTag},
+				{"--force", &opts.force},
+				{"--push", &opts.push}}
+			for _, opt := range boolopts {
+				if len(args) > 0 && args[0] == opt.flag {
+					*opt.target = true
+					args = args[1:]
+				}
 			}
+			//
 			if curr == len(args) {
+				// Not an option; the last argument is the path.
+				if len(args) == 1 {
+					opts.path = args[0]
+				}
 				// Nothing done, so remove one to avoid infinite loop
 				args = args[1:]
 			}
 		}
 		// End options parsing.
+		given := opts
+		// The format decides the default GOFILE so check it before it is used.
+		if opts.format != "" && sub != "changelog" && gv.GenerateFileName(opts.format) == "" {
+			fmt.Printf("Error: unknown format: %v\n", opts.format)
+			exitCode = 1
+			return
+		}
+		// The argument to extract and inspect is the binary rather than PATH.
+		if sub == "extract" || sub == "inspect" {
+			opts.binary, opts.path = opts.path, ""
+		}
+		// When run by 'go generate' the package and directory of the file
+		// with the directive are the defaults.
+		if gogen := gv.GetGoGenerateEnv(); gogen != nil && sub == "generate" {
+			if opts.path == "" {
+				opts.path = gogen.Dir
+			}
+			if opts.dashn == "" {
+				opts.dashn = gogen.Package
+			}
+		}
 		// Path wasn't provided
 		if opts.path == "" {
 			if sub == "checkout" {
@@ -113,41 +233,162 @@ func main() {
 		if opts.file == "" {
 			opts.file = filepath.Join(opts.path, "gogetvers.manifest")
 		}
-		// The following commands require that FILE exists: checkout, generate, print, rebuild
-		if sub == "checkout" || sub == "generate" || sub == "print" || sub == "rebuild" {
+		// The following commands require that FILE exists: checkout, generate, ldflags, print, rebuild
+		if sub == "checkout" || sub == "generate" || sub == "ldflags" || sub == "print" || sub == "rebuild" {
 			if !gv.IsFile(opts.file) {
 				fmt.Println(fmt.Sprintf("Error: FILE is not a file: %v", opts.file))
 				exitCode = 1
 				return
 			}
 		}
-		// Create our GGV object.
-		goget, err = gv.NewGoGetVers(opts.path, opts.file, os.Stdout)
+		// Create our GGV object; changelog and ldflags print their result
+		// to standard output so status goes to standard error.
+		status := os.Stdout
+		if sub == "changelog" || sub == "ldflags" {
+			status = os.Stderr
+		}
+		goget, err = gv.NewGoGetVers(opts.path, opts.file, status)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err.Error())
 			exitCode = 1
 			return
 		}
+		// If config is not provided then "gogetvers.config" within PATH is used if it exists.
+		if opts.config == "" && gv.IsFile(filepath.Join(opts.path, gv.ConfigFileName)) {
+			opts.config = filepath.Join(opts.path, gv.ConfigFileName)
+		}
+		if opts.config != "" {
+			config, err := gv.LoadConfigFile(opts.config)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err.Error())
+				exitCode = 1
+				return
+			}
+			goget.ApplyConfig(config)
+		}
+		// Toolchain options override the config.
+		if opts.gitbin != "" || opts.gobin != "" || len(opts.env) > 0 {
+			if goget.Toolchain == nil {
+				goget.Toolchain = &gv.Toolchain{}
+			}
+			if opts.gitbin != "" {
+				goget.Toolchain.Git = opts.gitbin
+			}
+			if opts.gobin != "" {
+				goget.Toolchain.Go = opts.gobin
+			}
+			goget.Toolchain.Env = append(goget.Toolchain.Env, opts.env...)
+		}
+		if opts.dryrun {
+			goget.Plan = gv.NewPlan()
+		}
+		goget.GenerateOptions.Template = opts.dashT
+		goget.GenerateOptions.Format = opts.format
+		goget.GenerateOptions.HTTP = opts.http
+		goget.GenerateOptions.Embed = opts.embed
+		goget.GenerateOptions.VarName = opts.varName
+		goget.GenerateOptions.TypeName = opts.typeName
+		goget.GenerateOptions.BuildConstraint = opts.build
+		// Release options add to the config.
+		if opts.commitFirst {
+			goget.ReleaseOptions.CommitFirst = true
+		}
+		if opts.requireTagged || len(opts.allowUntagged) > 0 {
+			goget.ReleaseOptions.RequireTagged = true
+		}
+		goget.ReleaseOptions.AllowUntagged = append(goget.ReleaseOptions.AllowUntagged, opts.allowUntagged...)
+		if opts.remote != "" {
+			goget.ReleaseOptions.Remote = opts.remote
+			goget.TagOptions.Remote = opts.remote
+		}
+		// Tag options add to the config.
+		if opts.force {
+			goget.TagOptions.Force = true
+		}
+		if opts.push {
+			goget.TagOptions.Push = true
+		}
+		if opts.pushBranch {
+			goget.ReleaseOptions.PushBranch = true
+		}
+		if opts.checkUpstream {
+			goget.ReleaseOptions.CheckUpstream = true
+		}
+		// Verify options add to the config; --verify is for release and
+		// --verify-tag for tag.  --verify-cmd implies the one for sub.
+		if opts.verify || (sub == "release" && len(opts.verifyCmds) > 0) {
+			goget.VerifyOptions.Release = true
+		}
+		if opts.verifyTag || (sub == "tag" && len(opts.verifyCmds) > 0) {
+			goget.VerifyOptions.Tag = true
+		}
+		for _, cmd := range opts.verifyCmds {
+			args := gv.SplitQuoted(cmd)
+			if len(args) == 0 {
+				fmt.Println("Error: --verify-cmd is empty")
+				exitCode = 1
+				return
+			}
+			goget.VerifyOptions.Commands = append(goget.VerifyOptions.Commands, args)
+		}
+		// Retry network commands if requested.
+		if opts.retries != "" {
+			goget.Retry = &gv.RetryPolicy{Delay: gv.DefaultRetryDelay}
+			goget.Retry.Attempts, err = strconv.Atoi(opts.retries)
+			if err == nil && opts.retryDelay != "" {
+				goget.Retry.Delay, err = time.ParseDuration(opts.retryDelay)
+			}
+			if err != nil {
+				fmt.Printf("Error: %v\n", err.Error())
+				exitCode = 1
+				return
+			}
+			// Retries is the number of attempts after the first.
+			goget.Retry.Attempts = goget.Retry.Attempts + 1
+		}
+		// Trace commands to a file if requested.
+		if opts.trace == "" {
+			opts.trace = os.Getenv(gv.TraceEnvVar)
+		}
+		if opts.trace != "" {
+			fw, err := os.OpenFile(opts.trace, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0660)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err.Error())
+				exitCode = 1
+				return
+			}
+			defer fw.Close()
+			goget.Runner = gv.NewTraceRunner(goget.Runner, fw)
+		}
 		// Generate defaults for -g and -n for 'generate', 'release', and 'tag'
 		if sub == "generate" || sub == "release" || sub == "tag" {
 			if opts.dashg == "" {
-				opts.dashg = filepath.Join(goget.Path, "generated_gogetvers.go")
+				opts.dashg = filepath.Join(goget.Path, gv.GenerateFileName(opts.format))
 			}
 			if opts.dashn == "" {
 				// Obtain automatically
-				cmd := gv.NewCommand("go", "list", "-f", "{{.Name}}")
-				err := cmd.Exec(goget.Path)
+				name, err := goget.PackageName()
 				if err == nil {
-					opts.dashn = cmd.Output
+					opts.dashn = name
 				}
 			}
 		}
 		// Do the work.
 		switch sub {
+		case "changelog":
+			err = dochangelog(opts.from, opts.to, opts.format)
 		case "checkout":
 			err = docheckout()
+		case "extract":
+			err = doextract(opts.binary, opts.force)
 		case "generate":
 			err = dogenerate(opts.dashg, opts.dashn)
+		case "init":
+			err = doinit(opts.dashi, given)
+		case "inspect":
+			err = doinspect(opts.binary, opts.file, given.file != "")
+		case "ldflags":
+			err = doldflags(opts.dashp)
 		case "make":
 			err = domake()
 		case "print":
@@ -155,12 +396,16 @@ func main() {
 		case "rebuild":
 			err = dorebuild()
 		case "release":
-			err = dorelease(opts.dashg, opts.dashn, opts.dasht, opts.dashm)
+			err = dorelease(opts.dashg, opts.dashn, opts.dasht, opts.dashm, opts.bump)
 		case "tag":
 			err = dotag(opts.dashg, opts.dashn, opts.dasht)
 		default:
 			err = errors.New("no sub command")
 		}
+		if goget.Plan != nil {
+			fmt.Println("Dry run; the following would be performed:")
+			fmt.Print(goget.Plan.String())
+		}
 		if err != nil {
 			fmt.Println("Error:", err.Error())
 			exitCode = 1
@@ -171,6 +416,90 @@ func main() {
 	}
 }
 
+func doinit(file string, given options) error {
+	if file == "" {
+		return errors.New("-i FILE is required")
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(goget.Path, file)
+	}
+	// Pass along the generate options that were given to init.
+	directive := []string{"//go:generate", "gogetvers", "generate"}
+	for _, opt := range []struct {
+		flag  string
+		value string
+	}{
+		{"-f", given.file},
+		{"-g", given.dashg},
+		{"-n", given.dashn},
+		{"-T", given.dashT},
+		{"--format", given.format},
+		{"--var", given.varName},
+		{"--type", given.typeName},
+		{"--build", given.build}} {
+		if opt.value != "" {
+			value := opt.value
+			if strings.ContainsAny(value, " \t\"") {
+				value = strconv.Quote(value)
+			}
+			directive = append(directive, opt.flag, value)
+		}
+	}
+	if given.http {
+		directive = append(directive, "--http")
+	}
+	if given.embed {
+		directive = append(directive, "--embed")
+	}
+	return goget.InsertGoGenerate(file, strings.Join(directive, " "))
+}
+
+func dochangelog(from, to, format string) error {
+	if from == "" {
+		return errors.New("--from TAG is required")
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+	changelog, err := goget.Changelog(from, to)
+	if err != nil {
+		return err
+	}
+	output, err := changelog.Format(format)
+	if err != nil {
+		return err
+	}
+	fmt.Print(output)
+	return nil
+}
+
+func doextract(binary string, force bool) error {
+	if binary == "" {
+		return errors.New("BINARY is required")
+	}
+	return goget.Extract(binary, force)
+}
+
+func doinspect(binary, file string, fileGiven bool) error {
+	if binary == "" {
+		return errors.New("BINARY is required")
+	}
+	// An explicit MANIFEST must exist; the default is only used if it does.
+	if fileGiven && !gv.IsFile(file) {
+		return errors.New(fmt.Sprintf("FILE is not a file: %v", file))
+	}
+	return goget.Inspect(binary, gv.IsFile(file))
+}
+
+func doldflags(importPath string) error {
+	flags, err := goget.Ldflags(importPath)
+	if err != nil {
+		return err
+	}
+	fmt.Println(flags)
+	return nil
+}
+
 func domake() error {
 	return goget.Make()
 }
@@ -187,7 +516,17 @@ func dorebuild() error {
 	return goget.Rebuild()
 }
 
-func dorelease(gofile, packageName, tag, message string) error {
+func dorelease(gofile, packageName, tag, message, bump string) error {
+	if bump != "" {
+		if tag != "" {
+			return errors.New("-t and --bump can not both be given")
+		}
+		var err error
+		tag, err = goget.NextTag(bump)
+		if err != nil {
+			return err
+		}
+	}
 	if message == "" {
 		message = tag + " by gogetvers"
 	}
@@ -226,6 +565,38 @@ For all proceeding commands:
       within PATH
     + If omitted then -g option defaults to generated_gogetvers.go
       within PATH
+    + If omitted then -c option defaults to gogetvers.config within
+      PATH if that file exists; see CONFIG below.
+    + --git BIN and --go BIN set the git and go executables; they
+      default to git and go from the PATH environment variable.
+    + --env KEY=VALUE adds KEY=VALUE to the environment of every
+      git and go command (e.g. GIT_SSH_COMMAND, GOFLAGS, GOPATH,
+      GOROOT); it can be given more than once.
+    + --dry-run prints the commands that would change a repository
+      and the files that would be written instead of running or
+      writing them; commands that only read information still run.
+      git fetch, the --verify checks and hooks are also printed
+      rather than run since they can change things.
+    + --trace FILE appends a JSON line to FILE for every git or go
+      command that is run: binary (after --git or --go), arguments,
+      environment added by --env, directory, duration, exit code
+      and output sizes.  The GOGETVERS_TRACE environment variable
+      sets FILE if the option is omitted.
+    + --retries N retries a failing clone, fetch, push or ls-remote
+      up to N times.  The wait before the first retry is given by
+      --retry-delay DURATION (e.g. 500ms, 2s; default 1s) and is
+      doubled for each retry after that.
+
+gogetvers changelog [-f MANIFEST] --from TAG [--to TAG] [--format FORMAT] [PATH]
+    Print the changes to the project at PATH from one release to
+    another; --to defaults to HEAD.  The changes are the subjects
+    of the project's commits and, from the MANIFEST describing
+    each TAG, the dependencies that were added, removed or moved
+    with the subjects of their commits in between.  The MANIFEST
+    describing a TAG made by release without --commit-first is in
+    the release commit after TAG.  Dependencies must be on disk
+    where the manifest places them.  FORMAT is markdown, the
+    default, or json.
 
 gogetvers checkout [-f MANIFEST] [PATH]
     Does the same as the 'rebuild' command with the following
@@ -237,18 +608,99 @@ gogetvers checkout [-f MANIFEST] [PATH]
     If any of the dependencies have local modifications then
     no work is performed.
 
-gogetvers generate [-f MANIFEST] [-g GOFILE] [-n PACKAGENAME] [PATH]
+gogetvers generate [-f MANIFEST] [-g GOFILE] [-n PACKAGENAME] [-T TEMPLATE]
+                   [--format FORMAT] [--http] [--embed] [--var NAME]
+                   [--type NAME] [--build CONSTRAINT] [PATH]
     Create a go source file with version information at PATH
     using MANIFEST file.  GOFILE is the output filename or 
     generated_gogetvers.go if omitted. If PACKAGENAME is omitted
     gogetvers will try and auto-detect it; if that fails then
     it will be read from the MANIFEST file.
+    TEMPLATE is a text/template file used instead of the built in
+    template.  Its data is the MANIFEST (e.g. {{.Git.Describe}},
+    {{.Git.Hash}}, {{.DepsGit}}, {{.DepsBuiltin}}, {{.DepsUntracked}})
+    plus {{.PackageName}}, {{.VarName}}, {{.TypeName}} and {{.Gits}},
+    the sorted gits of the package and its dependencies,
+    {{.VersionData}}, the version information as the json format
+    has it, and {{.Variables}}, the names and values used by the
+    env, make and header formats.  The following functions are
+    available to the template:
+      + quote      Go string literal, as strconv.Quote.
+      + join       strings.Join.
+      + describe   parts of a git describe, e.g.
+                   {{(describe .Git.Describe).Tag}}; also .Commits,
+                   .Hash and .SemVer.
+      + shquote    POSIX shell single quoted string.
+      + makequote  Makefile assignment value.
+      + cquote     C string literal.
+      + upper      upper case with underscores, e.g. VERSION_INFO.
+      + json       indented JSON.
+    FORMAT is one of the following; GOFILE defaults to the file
+    name shown for each:
+      + go      Go source (generated_gogetvers.go); the default.
+      + json    JSON document (gogetvers.json).
+      + env     Shell variable assignments (gogetvers.env).
+      + make    Makefile include (gogetvers.mk).
+      + header  C header (gogetvers.h).
+    The non-Go formats name their variables with VERSION_INFO_ as
+    a prefix, e.g. VERSION_INFO_VERSION and VERSION_INFO_HASH.  A
+    TEMPLATE is used as is for them; for go it must be Go source.
+    --http makes the generated Go type an http.Handler that serves
+    the version information as JSON and publishes the variable
+    with expvar, e.g. http.Handle("/version", VersionInfo).
+    --var and --type name the generated variable and type; they
+    default to VersionInfo and the variable name followed by Type
+    so more than one generated file can exist in a package.
+    --build adds a //go:build CONSTRAINT line to the Go output,
+    e.g. --build 'linux && !race'.
+    --embed adds the MANIFEST, compressed, to the Go output so it
+    is carried in binaries built with it; the generated type's
+    Manifest method returns it and 'gogetvers extract' recovers
+    it from a binary.
+    When run by 'go generate' PATH defaults to the directory of the
+    file with the directive and PACKAGENAME to its package.
+
+gogetvers init -i FILE [generate options] [PATH]
+    Insert a '//go:generate gogetvers generate' directive after
+    the package clause of FILE, a go source file in PATH, so that
+    'go generate' runs generate.  The generate options given to
+    init (-f, -g, -n, -T, --format, --http, --embed, --var, --type
+    and --build) are added to the directive as given; relative paths
+    are relative to PATH.  Nothing is done if FILE already has a
+    directive that runs gogetvers.
+
+gogetvers extract [--force] [-f MANIFEST] BINARY
+    Write the manifest embedded in BINARY by 'generate --embed'
+    to MANIFEST, which defaults to gogetvers.manifest in the
+    current directory.  An existing, different MANIFEST is only
+    replaced with --force.  The manifest can then be used with
+    checkout or rebuild to recreate the source tree of BINARY.
+
+gogetvers inspect [-f MANIFEST] BINARY
+    Print the version information in the Go binary BINARY without
+    running it: the build information recorded by go build (Go
+    version, module versions, vcs.revision and -ldflags -X values),
+    the version information of a file made by generate and the
+    manifest embedded by 'generate --embed'.  If MANIFEST, which
+    defaults to gogetvers.manifest in the current directory,
+    exists then any differences between it and BINARY are flagged
+    and the exit status is non-zero.  Hashes are not compared for
+    a manifest made by 'release --commit-first', which has none.
+
+gogetvers ldflags [-f MANIFEST] [-p IMPORTPATH] [PATH]
+    Print a -ldflags value for 'go build' that sets the variables
+    of package IMPORTPATH from MANIFEST; IMPORTPATH defaults to
+    gogetvers/version which is made for this purpose.  This
+    injects version information at build time instead of
+    committing a file made with generate:
+        go build -ldflags "$(gogetvers ldflags)"
 
 gogetvers make [-f FILE] [PATH]
     Create manifest information for golang package at PATH; or
     in current directory if PATH is omitted. FILE can be used
     to specify the output location of the manifest information;
     default FILE is gogetvers.manifest in PATH.
+    The output of 'go version' is recorded in the manifest.
 
 gogetvers print [-f MANIFEST] | [PATH]
     Print a summary of the MANIFEST file in PATH.  PATH
@@ -261,28 +713,149 @@ gogetvers rebuild [-f MANIFEST] [PATH]
     the dependencies described by MANIFEST already exist on
     the file system then no work is performed.
 
-gogetvers release [-g GOFILE] [-n PACKAGENAME] [-m MESSAGE] -t TAG [PATH]
+gogetvers release [-g GOFILE] [-n PACKAGENAME] [-m MESSAGE]
+                  [--commit-first] [--require-tagged]
+                  [--allow-untagged NAME]... [--remote REMOTE]
+                  [--push-branch] [--check-upstream] [--verify]
+                  [--verify-cmd CMD]... -t TAG | --bump PART [PATH]
     Creates an annotated tag for a project.  The following
     commands are performed:
       + git tag -a TAG [-m MESSAGE]
-      + git push origin TAG
+      + git push REMOTE TAG
       + gogetvers make PATH
       + gogetvers generate -g GOFILE -n PACKAGENAME PATH
       + git add . && git commit [-m MESSAGE]
+      + git push REMOTE BRANCH:UPSTREAM, with --push-branch
     If omitted PATH will be the current directory.  Release
     requires that the project at PATH and all of its dependencies
     do not have local modifications.  This is a convenience
     command to make a release version of a package.
+    If any step fails then the steps already performed are undone
+    in reverse order: the project is reset to the commit it was
+    at, the manifest and GOFILE it created are removed, the tag is
+    deleted from REMOTE and the tag is deleted.
+    Each step that was rolled back is reported.
+    --bump PART computes TAG from the latest tag of the project
+    instead, where PART is major, minor, patch or prerelease, e.g.
+    a minor bump of 1.2.3 is 1.3.0 and a prerelease bump of
+    1.3.0-rc.1 is 1.3.0-rc.2.  The 'v' prefix of the latest tag is
+    kept.  It is an error if TAG is not greater than every tag
+    that is a semantic version.
+    --commit-first changes the order so that the tag is on the
+    commit with the manifest and generated file:
+      + gogetvers make PATH, describing the project as TAG
+      + gogetvers generate -g GOFILE -n PACKAGENAME PATH
+      + git add . && git commit [-m MESSAGE]
+      + git tag -a TAG [-m MESSAGE]
+      + git push REMOTE TAG
+      + git push REMOTE BRANCH:UPSTREAM, with --push-branch
+    Since a commit can't contain its own hash the manifest then
+    names the project by TAG alone, with an empty hash, and the
+    generated version is TAG; checkout checks out TAG.
+    --require-tagged refuses to release if any dependency is not
+    at a tagged commit, i.e. its describe has commits past a tag
+    or no tag at all; the offending dependencies are listed.
+    --allow-untagged NAME exempts the dependency NAME, e.g.
+    github.com/user/repo, or those matching a pattern such as
+    github.com/user/*; it can be given more than once and implies
+    --require-tagged.
+    --remote REMOTE is the remote to push to; the default is
+    origin.  --push-branch also pushes the current branch, BRANCH,
+    to the branch it tracks on REMOTE, UPSTREAM, so the release
+    commit is published; UPSTREAM is BRANCH if it tracks none.
+    It and --check-upstream refuse to release if BRANCH tracks a
+    branch on a remote other than REMOTE.
+    --check-upstream fetches REMOTE and refuses to release if the
+    current branch is behind its upstream or has none.
+    --verify runs the following in PATH before anything is tagged
+    or committed and refuses to release if any of them fails,
+    printing the failing command's output:
+      + go build PATH (without writing a binary)
+      + go vet PATH
+      + go test PATH
+    --verify-cmd CMD runs CMD, e.g. 'make check', instead; it can
+    be given more than once and implies --verify.  CMD is split on
+    white space except that an argument starting with ' or " ends at
+    the matching quote, as go build splits -ldflags, e.g.
+    --verify-cmd "go test -run 'TestA|TestB' ./..."; there are no
+    escapes.
 
-gogetvers tag [-g GOFILE] [-n PACKAGENAME] -t TAG [PATH]
+gogetvers tag [-g GOFILE] [-n PACKAGENAME] [--verify-tag] [--verify-cmd CMD]...
+              [--force] [--push] [--remote REMOTE] -t TAG [PATH]
     Tag is similar to 'release' except the tag is not annotated and
     the check for local modifications is not performed.  This command
 	is suitable for tagging development or feature branches.  The
     following commands are performed:
-      + git tag -d TAG
+      + git tag -d TAG, if TAG exists
       + git tag TAG
+      + git push REMOTE TAG, with --push
       + gogetvers make PATH
       + gogetvers generate -g GOFILE -n PACKAGENAME PATH
+    An existing lightweight TAG is replaced but tag refuses to
+    replace an annotated TAG, such as one made by 'release', or a
+    TAG that exists on REMOTE unless --force is given.  A replaced
+    annotated TAG is re-created annotated with its original message
+    and tagger.  REMOTE is only checked if it is configured; the
+    default is origin.  If REMOTE can't be reached that is only an
+    error with --push.
+    --push pushes TAG to REMOTE, replacing it there with --force.
+    --verify-tag runs the checks of 'release --verify' first and
+    --verify-cmd, which implies --verify-tag, is as for 'release'.
+
+CONFIG
+    The config file is JSON and every member is optional; options
+    given on the command line take precedence over it.
+    {
+        "Toolchain": {
+            "Git": "/usr/bin/git",
+            "Go": "/usr/local/go1.9/bin/go",
+            "Env": ["GOFLAGS=-v", "GIT_SSH_COMMAND=ssh -i deploy_key"]
+        },
+        "Release": {
+            "CommitFirst": true,
+            "RequireTagged": true,
+            "AllowUntagged": ["github.com/user/*"],
+            "Remote": "origin",
+            "PushBranch": true,
+            "CheckUpstream": true
+        },
+        "Verify": {
+            "Release": true,
+            "Tag": false,
+            "Commands": [["make", "check"], ["go", "test", "./..."]]
+        },
+        "Tag": {
+            "Force": false,
+            "Push": true,
+            "Remote": "origin"
+        },
+        "Hooks": {
+            "Pre": {
+                "generate": [["make", "proto"]]
+            },
+            "Post": {
+                "release": [["./upload.sh"], ["sh", "-c", "notify \"$GOGETVERS_TAG\""]]
+            }
+        }
+    }
+    Hooks are commands run in PATH before (Pre) and after (Post) the
+    checkout, generate, make, rebuild, release and tag commands.  A
+    failing Pre hook aborts the command; Post hooks run only if the
+    command succeeds and a failing Post hook doesn't undo it; for
+    release the error says that TAG was released and pushed so that
+    it isn't released again.  Commands run by other commands, e.g.
+    make by release, don't run their hooks.  Hooks are given the
+    following environment variables:
+      + GOGETVERS_OPERATION  the command, e.g. release
+      + GOGETVERS_STAGE      pre or post
+      + GOGETVERS_PATH       PATH
+      + GOGETVERS_MANIFEST   MANIFEST
+      + GOGETVERS_GOFILE     GOFILE; generate, release and tag
+      + GOGETVERS_PACKAGE    PACKAGENAME; generate, release and tag
+      + GOGETVERS_TAG        TAG; release and tag
+      + GOGETVERS_MESSAGE    MESSAGE; release
+      + GOGETVERS_VERSION    describe of the project, if known
+      + GOGETVERS_HASH       commit hash of the project, if known
 `
-	fmt.Printf(usage)
+	fmt.Print(usage)
 }
@@ -7,7 +7,6 @@ import (
 	"os"
 	"os/exec"
 	"strings"
-	"syscall"
 )
 
 // Interface for a function that post-processes command output.
@@ -18,8 +17,20 @@ type Command struct {
 	Bin             string
 	Args            []string
 	Output          string
+	ErrorOutput     string // Standard error of the command.
 	ExitCode        int
 	OutputProcessor FuncCommandOutputProcessor
+	Mutates         bool     // True if the command changes a repository or the file system.
+	Network         bool     // True if the command talks to a remote repository.
+	Env             []string // KEY=VALUE pairs added to the inherited environment.
+	toolchain       *Toolchain
+}
+
+// Creates a 'git add path...' command.
+func NewCommandGitAdd(path ...string) *Command {
+	rv := NewCommand("git", append([]string{"add"}, path...)...)
+	rv.Mutates = true
+	return rv
 }
 
 // Creates a 'git branch' command.
@@ -27,14 +38,56 @@ func NewCommandGitBranch() *Command {
 	return NewCommand("git", "branch")
 }
 
+// Creates a command from args, a binary and its arguments given by the
+// user, e.g. a hook, which must not be empty.  It could do anything so
+// it is marked Mutates.
+func NewCommandUser(args []string) *Command {
+	rv := NewCommand(args[0], args[1:]...)
+	rv.Mutates = true
+	return rv
+}
+
+// Creates a 'git push where branch:ref' command that pushes branch to
+// ref, e.g. refs/heads/master, on where.
+func NewCommandGitBranchPush(branch, ref, where string) *Command {
+	rv := NewCommand("git", "push", where, branch+":"+ref)
+	rv.Mutates = true
+	rv.Network = true
+	return rv
+}
+
+// Creates a 'git rev-parse --abbrev-ref HEAD' command; its output is HEAD
+// if no branch is checked out.
+func NewCommandGitCurrentBranch() *Command {
+	return NewCommand("git", "rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// Creates a 'git cat-file -t object' command; its output is the type of
+// object, e.g. tag for an annotated tag or commit for a lightweight one.
+func NewCommandGitCatFileType(object string) *Command {
+	return NewCommand("git", "cat-file", "-t", object)
+}
+
 // Creates a 'git checkout hash' command.
 func NewCommandGitCheckout(hash string) *Command {
-	return NewCommand("git", "checkout", hash)
+	rv := NewCommand("git", "checkout", hash)
+	rv.Mutates = true
+	return rv
 }
 
 // Creates a 'git clone -b branch origin outputDir' command.
 func NewCommandGitClone(branch, origin, outputDir string) *Command {
-	return NewCommand("git", "clone", "-b", branch, origin, outputDir)
+	rv := NewCommand("git", "clone", "-b", branch, origin, outputDir)
+	rv.Mutates = true
+	rv.Network = true
+	return rv
+}
+
+// Creates a 'git commit -m message' command.
+func NewCommandGitCommit(message string) *Command {
+	rv := NewCommand("git", "commit", "-m", message)
+	rv.Mutates = true
+	return rv
 }
 
 // Creates a 'git describe --tags --abbrev=8 --always --long' command.
@@ -42,44 +95,167 @@ func NewCommandGitDescribe() *Command {
 	return NewCommand("git", "describe", "--tags", "--abbrev=8", "--always", "--long")
 }
 
+// Creates a 'git fetch remote' command; it updates the remote-tracking
+// branches so it is planned rather than run by a dry run.
+func NewCommandGitFetch(remote string) *Command {
+	rv := NewCommand("git", "fetch", remote)
+	rv.Mutates = true
+	rv.Network = true
+	return rv
+}
+
 // Creates a 'git rev-parse HEAD' command.
 func NewCommandGitHash() *Command {
 	return NewCommand("git", "rev-parse", "HEAD")
 }
 
+// Creates a 'git log' command that prints the hash and subject, separated
+// by a tab, of each commit in revisions, e.g. 1.0.0..1.1.0.
+func NewCommandGitLog(revisions string) *Command {
+	return NewCommand("git", "log", "--format=%H%x09%s", revisions)
+}
+
+// Creates a 'git ls-remote remote ref...' command.
+func NewCommandGitLsRemote(remote string, ref ...string) *Command {
+	rv := NewCommand("git", append([]string{"ls-remote", remote}, ref...)...)
+	rv.Network = true
+	return rv
+}
+
 // Creates a 'git config --get remote.origin.url' command.
 func NewCommandGitOrigin() *Command {
 	return NewCommand("git", "config", "--get", "remote.origin.url")
 }
 
+// Creates a 'git config --get remote.remote.url' command.
+func NewCommandGitRemoteUrl(remote string) *Command {
+	return NewCommand("git", "config", "--get", "remote."+remote+".url")
+}
+
+// Creates a 'git config --get name' command.
+func NewCommandGitConfigGet(name string) *Command {
+	return NewCommand("git", "config", "--get", name)
+}
+
+// Creates a 'git rev-parse ref^{commit}' command that prints the hash of
+// the commit ref names.
+func NewCommandGitRevParseCommit(ref string) *Command {
+	return NewCommand("git", "rev-parse", ref+"^{commit}")
+}
+
+// Creates a 'git rev-list --ancestry-path --reverse --parents ^hash --all'
+// command that lists the descendants of the commit hash, oldest first,
+// each followed by its parents.
+func NewCommandGitRevListDescendants(hash string) *Command {
+	return NewCommand("git", "rev-list", "--ancestry-path", "--reverse", "--parents", "^"+hash, "--all")
+}
+
+// Creates a 'git rev-list --count revisions' command.
+func NewCommandGitRevListCount(revisions string) *Command {
+	return NewCommand("git", "rev-list", "--count", revisions)
+}
+
+// Creates a 'git show ref:path' command that prints the file at path as
+// of ref; path is relative to the working directory if it starts with ./.
+func NewCommandGitShow(ref, path string) *Command {
+	return NewCommand("git", "show", ref+":"+path)
+}
+
 // Creates a 'git status --porcelain' command.
 func NewCommandGitStatus() *Command {
 	return NewCommand("git", "status", "--porcelain")
 }
 
+// Creates a 'git rev-parse --abbrev-ref @{upstream}' command that prints
+// the upstream of the current branch, e.g. origin/master.
+func NewCommandGitUpstream() *Command {
+	return NewCommand("git", "rev-parse", "--abbrev-ref", "@{upstream}")
+}
+
 // Creates a 'git tag tag' command.
 func NewCommandGitTag(tag string) *Command {
-	return NewCommand("git", "tag", tag)
+	rv := NewCommand("git", "tag", tag)
+	rv.Mutates = true
+	return rv
+}
+
+// Creates a 'git tag --list' command.
+func NewCommandGitTagList() *Command {
+	return NewCommand("git", "tag", "--list")
 }
 
 // Creates a 'git tag -m message -a tag' command.
 func NewCommandGitTagAnnotated(tag, message string) *Command {
-	return NewCommand("git", "tag", "-m", message, "-a", tag)
+	rv := NewCommand("git", "tag", "-m", message, "-a", tag)
+	rv.Mutates = true
+	return rv
 }
 
 // Creates a 'git push where tag' command.
 func NewCommandGitTagPush(tag, where string) *Command {
-	return NewCommand("git", "push", where, tag)
+	rv := NewCommand("git", "push", where, tag)
+	rv.Mutates = true
+	rv.Network = true
+	return rv
+}
+
+// Creates a 'git push where +refs/tags/tag' command that replaces the tag
+// on where even if it points elsewhere.
+func NewCommandGitTagPushForce(tag, where string) *Command {
+	rv := NewCommand("git", "push", where, "+refs/tags/"+tag)
+	rv.Mutates = true
+	rv.Network = true
+	return rv
+}
+
+// Creates a 'git push where :refs/tags/tag' command that deletes the tag
+// from where.
+func NewCommandGitTagPushDelete(tag, where string) *Command {
+	rv := NewCommand("git", "push", where, ":refs/tags/"+tag)
+	rv.Mutates = true
+	rv.Network = true
+	return rv
+}
+
+// Creates a 'git reset --hard hash' command.
+func NewCommandGitResetHard(hash string) *Command {
+	rv := NewCommand("git", "reset", "--hard", hash)
+	rv.Mutates = true
+	return rv
+}
+
+// Creates a 'git clean -f -x -- paths' command that removes the untracked
+// files paths, even if they are ignored.
+func NewCommandGitCleanFiles(paths ...string) *Command {
+	rv := NewCommand("git", append([]string{"clean", "-f", "-x", "--"}, paths...)...)
+	rv.Mutates = true
+	return rv
+}
+
+// Creates a 'git for-each-ref' command whose output is the tagger's name,
+// email and raw date and then the message of the annotated tag, separated
+// by NUL characters.
+func NewCommandGitTagAnnotation(tag string) *Command {
+	return NewCommand("git", "for-each-ref", "--format=%(taggername)%00%(taggeremail)%00%(taggerdate:raw)%00%(contents)", "refs/tags/"+tag)
 }
 
 // Creates a 'git tag -d tag' command.
 func NewCommandGitTagDelete(tag string) *Command {
-	return NewCommand("git", "tag", "-d", tag)
+	rv := NewCommand("git", "tag", "-d", tag)
+	rv.Mutates = true
+	return rv
+}
+
+// Creates a 'go build -o os.DevNull pkg...' command; nothing is written.
+func NewCommandGoBuild(pkg ...string) *Command {
+	return NewCommand("go", append([]string{"build", "-o", os.DevNull}, pkg...)...)
 }
 
 // Creates a 'go fmt file...' command.
 func NewCommandGoFmt(file ...string) *Command {
-	return NewCommand("go", append([]string{"fmt"}, file...)...)
+	rv := NewCommand("go", append([]string{"fmt"}, file...)...)
+	rv.Mutates = true
+	return rv
 }
 
 // Creates a 'go list' command.
@@ -87,6 +263,11 @@ func NewCommandGoList() *Command {
 	return NewCommand("go", "list")
 }
 
+// Creates a 'go list -f {{.Name}}' command.
+func NewCommandGoListName() *Command {
+	return NewCommand("go", "list", "-f", "{{.Name}}")
+}
+
 // Creates a 'go list -f {{.Deps}}' command and strips "[]" from output.
 func NewCommandGoListDeps() *Command {
 	rv := NewCommand("go", "list", "-f", "{{.Deps}}")
@@ -96,6 +277,21 @@ func NewCommandGoListDeps() *Command {
 	return rv
 }
 
+// Creates a 'go test pkg...' command.
+func NewCommandGoTest(pkg ...string) *Command {
+	return NewCommand("go", append([]string{"test"}, pkg...)...)
+}
+
+// Creates a 'go vet pkg...' command.
+func NewCommandGoVet(pkg ...string) *Command {
+	return NewCommand("go", append([]string{"vet"}, pkg...)...)
+}
+
+// Creates a 'go version' command.
+func NewCommandGoVersion() *Command {
+	return NewCommand("go", "version")
+}
+
 // Creates a new command type.
 func NewCommand(bin string, args ...string) *Command {
 	rv := &Command{Bin: bin, Args: []string{}, ExitCode: -1}
@@ -113,93 +309,50 @@ func (cmd *Command) String() string {
 	return strings.Join(append([]string{cmd.Bin}, cmd.Args...), " ")
 }
 
+// Returns the executable Exec runs and the environment it adds, which
+// include those of the command's Toolchain.
+func (cmd *Command) resolve() (string, []string) {
+	if cmd.toolchain == nil {
+		return cmd.Bin, cmd.Env
+	}
+	return cmd.toolchain.bin(cmd.Bin), append(append([]string{}, cmd.toolchain.Env...), cmd.Env...)
+}
+
 // Executes the command; if chdir is not an empty string then
-// the working directory is changed into chdir.  Using chdir
-// is not goroutine safe.
+// the command is run with chdir as its working directory.
 func (cmd *Command) Exec(chdir string) error {
 	if cmd == nil {
 		return errors.New("nil receiver")
 	}
 	// Exit code and standard output.
 	cmd.Output = ""
+	cmd.ErrorOutput = ""
 	cmd.ExitCode = -1
-	// Catch errors
-	var err error
-	// Done channel tells us when command is done.
-	done := make(chan error, 1)
 	// Create command.
-	runme := exec.Command(cmd.Bin, cmd.Args...)
-	// Standard output handler
-	stdoutRet := make(chan bool, 1)
-	defer func() {
-		// Trim output but not until after the stdout goroutine is done.
-		select {
-		case <-stdoutRet:
-		}
-		cmd.Output = strings.TrimSpace(cmd.Output)
-		if cmd.OutputProcessor != nil {
-			cmd.Output = cmd.OutputProcessor(cmd.Output)
-		}
-	}()
-	stdoutDone := make(chan bool, 1)
-	defer func() { stdoutDone <- true }()
-	stdoutRdr, err := runme.StdoutPipe()
-	if err != nil {
-		return err
+	bin, env := cmd.resolve()
+	runme := exec.Command(bin, cmd.Args...)
+	runme.Dir = chdir
+	if len(env) > 0 {
+		runme.Env = append(os.Environ(), env...)
 	}
-	go func() {
-		for {
-			select {
-			case <-stdoutDone:
-				stdoutRet <- true
-				return
-			default:
-				dat := make([]byte, 256)
-				nn, _ := stdoutRdr.Read(dat)
-				if nn > 0 {
-					cmd.Output = cmd.Output + string(bytes.TrimRight(dat, "\x00"))
-				}
-			}
-		}
-	}()
-	// Start command
-	started := make(chan bool, 1)
-	go func() {
-		if chdir != "" {
-			cw, err := os.Getwd()
-			if err != nil {
-				return
-			}
-			defer os.Chdir(cw)
-			os.Chdir(chdir)
-		}
-		err = runme.Start()
-		started <- true
-		if err != nil {
-			return
-		}
-	}()
-
-	go func() {
-		select {
-		case <-started:
-		}
-		done <- runme.Wait()
-	}()
-	select {
-	case err = <-done:
-		cmd.ExitCode = 0
-		if err != nil {
-			if exiterr, ok := err.(*exec.ExitError); ok {
-				if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
-					cmd.ExitCode = status.ExitStatus()
-					err = nil
-				}
-			}
-		}
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	runme.Stdout = stdout
+	runme.Stderr = stderr
+	// Run and wait for command.
+	err := runme.Run()
+	cmd.Output = strings.TrimSpace(stdout.String())
+	if cmd.OutputProcessor != nil {
+		cmd.Output = cmd.OutputProcessor(cmd.Output)
 	}
+	cmd.ErrorOutput = strings.TrimSpace(stderr.String())
 	if err != nil {
-		return err
+		exiterr, ok := err.(*exec.ExitError)
+		if !ok {
+			return err
+		}
+		cmd.ExitCode = exiterr.ExitCode()
+	} else {
+		cmd.ExitCode = 0
 	}
 	if cmd.ExitCode != 0 {
 		return errors.New(fmt.Sprintf("%v returns %v", cmd.String(), cmd.ExitCode))
@@ -0,0 +1,47 @@
+package gogetvers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Default name of the configuration file within a package directory.
+const ConfigFileName = "gogetvers.config"
+
+// Config holds per project settings; it is stored as JSON.
+type Config struct {
+	Toolchain *Toolchain      // Binaries and environment for git and go commands.
+	Release   *ReleaseOptions // Options for Release.
+	Verify    *VerifyOptions  // Checks before Release and Tag.
+	Tag       *TagOptions     // Options for Tag.
+	Hooks     *Hooks          // Commands run before and after operations.
+}
+
+// Opens the input file and decodes the configuration.
+func LoadConfigFile(inputFile string) (*Config, error) {
+	if !IsFile(inputFile) {
+		return nil, errors.New(fmt.Sprintf("Not a file @ %v", inputFile))
+	}
+	fr, err := os.Open(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	defer fr.Close()
+	//
+	dec := json.NewDecoder(fr)
+	rv := &Config{}
+	err = dec.Decode(rv)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("%v @ %v", err.Error(), inputFile))
+	}
+	err = rv.Hooks.validate()
+	if err == nil {
+		err = rv.Verify.validate()
+	}
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("%v @ %v", err.Error(), inputFile))
+	}
+	return rv, nil
+}
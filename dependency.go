@@ -32,12 +32,20 @@ type UntrackedDependency struct {
 }
 
 func GetDependency(dependencyDir, rootDir string) (Dependency, error) {
+	return GetDependencyWithRunner(dependencyDir, rootDir, nil)
+}
+
+// Like GetDependency() but git commands are run with runner, and
+// directories are looked up with it if it is a DirChecker.  A dependency
+// that isn't a directory is a built in and one that isn't in a git is
+// untracked.
+func GetDependencyWithRunner(dependencyDir, rootDir string, runner Runner) (Dependency, error) {
 	name := strings.Replace(dependencyDir, rootDir, "", 1)
-	if !IsDir(dependencyDir) {
+	if !runnerIsDir(runner, dependencyDir) {
 		// Must be a golang built in
 		return &BuiltinDependency{Name: name, DependencyComposite: DependencyComposite{}}, nil
 	}
-	git, err := NewGitByFind(dependencyDir, rootDir)
+	git, err := NewGitByFindWithRunner(dependencyDir, rootDir, runner)
 	if err != nil {
 		// Not a git repo so not trackable
 		return &UntrackedDependency{Name: name, DependencyComposite: DependencyComposite{}}, nil
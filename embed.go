@@ -0,0 +1,166 @@
+package gogetvers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// Markers around the compressed manifest that Generate embeds in binaries.
+const (
+	ManifestMarkerBegin = "GOGETVERS-MANIFEST-BEGIN:"
+	ManifestMarkerEnd   = ":GOGETVERS-MANIFEST-END"
+)
+
+// Markers around the VersionData, as JSON, that the Go output of Generate
+// always carries so binaries can be inspected without --embed.
+const (
+	VersionMarkerBegin = "GOGETVERS-VERSION-BEGIN:"
+	VersionMarkerEnd   = ":GOGETVERS-VERSION-END"
+)
+
+// Returns vd as JSON between VersionMarkerBegin and VersionMarkerEnd.
+func encodeVersionData(vd *VersionData) (string, error) {
+	encoded, err := json.Marshal(vd)
+	if err != nil {
+		return "", err
+	}
+	return VersionMarkerBegin + string(encoded) + VersionMarkerEnd, nil
+}
+
+// Returns the manifest p as gzipped, base64 encoded JSON between
+// ManifestMarkerBegin and ManifestMarkerEnd.
+func encodeManifest(p *PackageInfo) (string, error) {
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	err := json.NewEncoder(zw).Encode(p)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		return "", err
+	}
+	return ManifestMarkerBegin + base64.StdEncoding.EncodeToString(buf.Bytes()) + ManifestMarkerEnd, nil
+}
+
+// Decodes a manifest made by encodeManifest; the markers are optional.
+func decodeManifest(encoded []byte) ([]byte, error) {
+	encoded = bytes.TrimPrefix(encoded, []byte(ManifestMarkerBegin))
+	encoded = bytes.TrimSuffix(encoded, []byte(ManifestMarkerEnd))
+	compressed := make([]byte, base64.StdEncoding.DecodedLen(len(encoded)))
+	nn, err := base64.StdEncoding.Decode(compressed, encoded)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed[:nn]))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return ioutil.ReadAll(zr)
+}
+
+// Calls decode with what is between each begin and end marker in the
+// file binary until it returns nil; returns an error if it never does.
+// The markers also appear on their own in binaries (e.g. gogetvers
+// itself) so every occurrence is tried.
+func findMarked(binary, begin, end string, decode func(marked []byte) error) error {
+	if !IsFile(binary) {
+		return errors.New(fmt.Sprintf("Not a file @ %v", binary))
+	}
+	contents, err := ioutil.ReadFile(binary)
+	if err != nil {
+		return err
+	}
+	for offset := 0; offset < len(contents); {
+		k := bytes.Index(contents[offset:], []byte(begin))
+		if k < 0 {
+			break
+		}
+		start := offset + k + len(begin)
+		offset = start
+		stop := bytes.Index(contents[start:], []byte(end))
+		if stop < 0 {
+			break
+		}
+		if decode(contents[start:start+stop]) == nil {
+			return nil
+		}
+	}
+	return errors.New(fmt.Sprintf("nothing between %v and %v in %v", begin, end, binary))
+}
+
+// Finds the manifest embedded by Generate in the binary and returns it.
+func ExtractManifest(binary string) ([]byte, error) {
+	var rv []byte
+	err := findMarked(binary, ManifestMarkerBegin, ManifestMarkerEnd, func(marked []byte) error {
+		manifest, err := decodeManifest(marked)
+		if err != nil {
+			return err
+		}
+		// It must also be a manifest.
+		err = json.Unmarshal(manifest, &PackageInfo{})
+		if err != nil {
+			return err
+		}
+		rv = manifest
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("no embedded manifest in %v", binary))
+	}
+	return rv, nil
+}
+
+// Finds the VersionData written by Generate in the binary and returns it.
+func ExtractVersionData(binary string) (*VersionData, error) {
+	var rv *VersionData
+	err := findMarked(binary, VersionMarkerBegin, VersionMarkerEnd, func(marked []byte) error {
+		vd := &VersionData{}
+		err := json.Unmarshal(marked, vd)
+		if err != nil {
+			return err
+		}
+		rv = vd
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("no version information in %v", binary))
+	}
+	return rv, nil
+}
+
+// Extracts the manifest embedded in the binary and writes it to File; an
+// existing File is only replaced if force is true.
+func (g *GoGetVers) Extract(binary string, force bool) error {
+	if g == nil {
+		return errors.New("nil receiver")
+	}
+	g.Status.Printf("Extracting manifest from %v\n", binary)
+	manifest, err := ExtractManifest(binary)
+	if err != nil {
+		g.Status.Error(err)
+		return err
+	}
+	if IsFile(g.File) && !force && FileDiffers(g.File, manifest) {
+		err = errors.New(fmt.Sprintf("%v exists; use force to replace it", g.File))
+		g.Status.Error(err)
+		return err
+	}
+	g.Status.Printf("Writing output to %v\n", g.File)
+	if g.Plan != nil {
+		g.Plan.AddFile("write", g.File)
+		return nil
+	}
+	_, err = WriteFileIfChanged(g.File, manifest, 0664)
+	if err != nil {
+		g.Status.Error(err)
+		return err
+	}
+	g.Status.Writeln("done")
+	return nil
+}
@@ -0,0 +1,93 @@
+package gogetvers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Returns the manifest with a dependency and its JSON as embedded.
+func embedManifest(t *testing.T) (*PackageInfo, []byte) {
+	info := NewPackageInfo("example.com/proj", "")
+	info.Git = &Git{HomeDir: "example.com/proj", Hash: fakeHash, Describe: "1.2.0-3-g0123abcd"}
+	info.DepsGit = []*GitDependency{{Name: "example.com/dep", Git: &Git{HomeDir: "example.com/dep", Hash: fakeDepHash, Describe: "0.4.0-0-g4567ef01"}}}
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(info); err != nil {
+		t.Fatal(err)
+	}
+	return info, buf.Bytes()
+}
+
+func TestEncodeManifest(t *testing.T) {
+	info, want := embedManifest(t)
+	encoded, err := encodeManifest(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encoded, ManifestMarkerBegin) || !strings.HasSuffix(encoded, ManifestMarkerEnd) {
+		t.Errorf("markers missing from %v", encoded)
+	}
+	bare := strings.TrimSuffix(strings.TrimPrefix(encoded, ManifestMarkerBegin), ManifestMarkerEnd)
+	for _, text := range []string{encoded, bare} {
+		got, err := decodeManifest([]byte(text))
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("decoded %s, %v; want %s", got, err, want)
+		}
+	}
+	if _, err = decodeManifest([]byte("not base64!")); err == nil {
+		t.Error("invalid manifest decoded")
+	}
+}
+
+func TestExtractEmbeddedManifest(t *testing.T) {
+	info, want := embedManifest(t)
+	main := "package main\n\nimport \"os\"\n\nfunc main() {\n\tmanifest, err := VersionInfo.Manifest()\n\tif err != nil {\n\t\tpanic(err)\n\t}\n\tos.Stdout.Write(manifest)\n}\n"
+	files := map[string]*TemplateData{"generated_gogetvers.go": generatedData(t, info, GenerateOptions{Embed: true})}
+	binary := buildGenerated(t, files, main)
+	if got, err := ExtractManifest(binary); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("extracted %s, %v; want %s", got, err, want)
+	}
+	if got, err := exec.Command(binary).Output(); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("Manifest() returned %s, %v; want %s", got, err, want)
+	}
+	// Extract writes the manifest but doesn't replace another without force.
+	dir := t.TempDir()
+	g, err := NewGoGetVers(dir, filepath.Join(dir, "gogetvers.manifest"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = g.Extract(binary, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := ioutil.ReadFile(g.File); !bytes.Equal(got, want) {
+		t.Errorf("wrote %s; want %s", got, want)
+	}
+	if err = g.Extract(binary, false); err != nil {
+		t.Errorf("extract of the same manifest returned %v", err)
+	}
+	if err = ioutil.WriteFile(g.File, []byte("{}"), 0664); err != nil {
+		t.Fatal(err)
+	}
+	if err = g.Extract(binary, false); err == nil {
+		t.Error("extract replaced another manifest without force")
+	}
+	if err = g.Extract(binary, true); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := ioutil.ReadFile(g.File); !bytes.Equal(got, want) {
+		t.Errorf("forced extract wrote %s; want %s", got, want)
+	}
+}
+
+func TestExtractWithoutEmbed(t *testing.T) {
+	info, _ := embedManifest(t)
+	main := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(VersionInfo.Version)\n}\n"
+	binary := buildGenerated(t, map[string]*TemplateData{"generated_gogetvers.go": generatedData(t, info, GenerateOptions{})}, main)
+	if _, err := ExtractManifest(binary); err == nil {
+		t.Error("manifest found in a binary built without --embed")
+	}
+}
@@ -0,0 +1,140 @@
+package gogetvers
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// A scripted result for a FakeRunner command.
+type FakeResult struct {
+	Output      string // Standard output of the command.
+	ErrorOutput string // Standard error of the command.
+	ExitCode    int    // Exit code of the command.
+	Err         error  // If non-nil then returned as if the command failed to start.
+}
+
+// A command received by a FakeRunner.
+type FakeCall struct {
+	Command string // Command.String() of the command.
+	Dir     string // Working directory the command was run in.
+}
+
+// FakeRunner is a scriptable Runner for tests and embedding; it never
+// executes anything and instead answers commands with scripted results.
+// Once a directory is added with AddDir it is also a DirChecker so that
+// packages and gits need not exist on disk.
+type FakeRunner struct {
+	Default *FakeResult // Result for unscripted commands; nil makes them fail.
+	Calls   []FakeCall  // Every command run, in order.
+	results map[fakeKey]*FakeResult
+	dirs    map[string]bool
+	mutex   sync.Mutex
+}
+
+type fakeKey struct {
+	dir     string
+	command string
+}
+
+// Creates a new FakeRunner with no scripted results.
+func NewFakeRunner() *FakeRunner {
+	return &FakeRunner{Calls: []FakeCall{}, results: make(map[fakeKey]*FakeResult)}
+}
+
+// Scripts output and exitCode for command (as given by Command.String())
+// when run in dir; an empty dir matches any directory.
+func (f *FakeRunner) Script(dir, command, output string, exitCode int) *FakeRunner {
+	return f.ScriptResult(dir, command, &FakeResult{Output: output, ExitCode: exitCode})
+}
+
+// Scripts an error for command when run in dir; an empty dir matches any directory.
+func (f *FakeRunner) ScriptError(dir, command string, err error) *FakeRunner {
+	return f.ScriptResult(dir, command, &FakeResult{ExitCode: -1, Err: err})
+}
+
+// Scripts result for command when run in dir; an empty dir matches any directory.
+func (f *FakeRunner) ScriptResult(dir, command string, result *FakeResult) *FakeRunner {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.results == nil {
+		f.results = make(map[fakeKey]*FakeResult)
+	}
+	f.results[fakeKey{dir, command}] = result
+	return f
+}
+
+// Adds directories that IsDir reports as existing; once any are added
+// the file system is no longer consulted.
+func (f *FakeRunner) AddDir(path ...string) *FakeRunner {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.dirs == nil {
+		f.dirs = make(map[string]bool)
+	}
+	for _, v := range path {
+		f.dirs[filepath.Clean(v)] = true
+	}
+	return f
+}
+
+// Satisfies DirChecker interface; uses the file system if no directories
+// were added with AddDir.
+func (f *FakeRunner) IsDir(path string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.dirs == nil {
+		return IsDir(path)
+	}
+	return f.dirs[filepath.Clean(path)]
+}
+
+// Returns the Command.String() of every command run, in order.
+func (f *FakeRunner) Commands() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	rv := []string{}
+	for _, call := range f.Calls {
+		rv = append(rv, call.Command)
+	}
+	return rv
+}
+
+// Satisfies Runner interface.
+func (f *FakeRunner) Run(cmd *Command, chdir string) error {
+	if cmd == nil {
+		return errors.New("nil command")
+	}
+	f.mutex.Lock()
+	f.Calls = append(f.Calls, FakeCall{Command: cmd.String(), Dir: chdir})
+	result, ok := f.results[fakeKey{chdir, cmd.String()}]
+	if !ok {
+		result, ok = f.results[fakeKey{"", cmd.String()}]
+	}
+	if !ok {
+		result = f.Default
+	}
+	f.mutex.Unlock()
+	//
+	cmd.Output = ""
+	cmd.ErrorOutput = ""
+	cmd.ExitCode = -1
+	if result == nil {
+		return errors.New(fmt.Sprintf("no scripted result for %v in %v", cmd.String(), chdir))
+	}
+	if result.Err != nil {
+		return result.Err
+	}
+	cmd.Output = strings.TrimSpace(result.Output)
+	if cmd.OutputProcessor != nil {
+		cmd.Output = cmd.OutputProcessor(cmd.Output)
+	}
+	cmd.ErrorOutput = strings.TrimSpace(result.ErrorOutput)
+	cmd.ExitCode = result.ExitCode
+	if cmd.ExitCode != 0 {
+		return errors.New(fmt.Sprintf("%v returns %v", cmd.String(), cmd.ExitCode))
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package gogetvers
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// Locations of the fake package made by newFakeGoGetVers.
+const (
+	fakeRoot    = "/fake/src"
+	fakePackage = "/fake/src/example.com/proj"
+	fakeDep     = "/fake/src/example.com/dep"
+	fakeHash    = "0123abcd0123abcd0123abcd0123abcd0123abcd"
+	fakeDepHash = "4567ef014567ef014567ef014567ef014567ef01"
+)
+
+// Scripts the commands NewGitWithRunner runs for the git at dir.
+func scriptFakeGit(fake *FakeRunner, dir, origin, hash, describe string) {
+	fake.AddDir(dir, filepath.Join(dir, ".git"))
+	fake.Script(dir, "git branch", "* master", 0)
+	fake.Script(dir, "git config --get remote.origin.url", origin, 0)
+	fake.Script(dir, "git rev-parse HEAD", hash, 0)
+	fake.Script(dir, "git status --porcelain", "", 0)
+	fake.Script(dir, "git describe --tags --abbrev=8 --always --long", describe, 0)
+}
+
+// Returns a GoGetVers for the package example.com/proj, which depends on
+// fmt and the git example.com/dep, that exists only in the returned
+// FakeRunner; the manifest is written to a temporary directory.
+func newFakeGoGetVers(t *testing.T) (*GoGetVers, *FakeRunner) {
+	fake := NewFakeRunner()
+	fake.AddDir(fakeRoot, filepath.Dir(fakePackage))
+	scriptFakeGit(fake, fakePackage, "https://example.com/proj.git", fakeHash, "1.2.0-3-g0123abcd")
+	scriptFakeGit(fake, fakeDep, "https://example.com/dep.git", fakeDepHash, "0.4.0-0-g4567ef01")
+	fake.Script(fakePackage, "go list", "example.com/proj", 0)
+	fake.Script(fakePackage, "go list -f {{.Deps}}", "[example.com/dep fmt]", 0)
+	fake.Script(fakePackage, "go version", "go version go1.22.1 linux/amd64", 0)
+	g, err := NewGoGetVers(fakePackage, filepath.Join(t.TempDir(), "gogetvers.manifest"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Runner = fake
+	return g, fake
+}
+
+func TestMakeWithFakeRunner(t *testing.T) {
+	g, _ := newFakeGoGetVers(t)
+	if err := g.Make(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := LoadPackageInfoFile(g.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.PackageDir != "example.com/proj" || info.RootDir != "" {
+		t.Errorf("package %v in root %v", info.PackageDir, info.RootDir)
+	}
+	if info.Git.Hash != fakeHash || info.Git.Describe != "1.2.0-3-g0123abcd" || info.Git.Branch != "master" {
+		t.Errorf("package git %+v", info.Git)
+	}
+	if info.GoVersion != "go version go1.22.1 linux/amd64" {
+		t.Errorf("go version %v", info.GoVersion)
+	}
+	if len(info.DepsBuiltin) != 1 || info.DepsBuiltin[0].Name != "/fmt" {
+		t.Errorf("built ins %v", info.getBuiltinNames())
+	}
+	if len(info.DepsGit) != 1 || info.DepsGit[0].Git.HomeDir != "example.com/dep" || info.DepsGit[0].Git.Hash != fakeDepHash {
+		t.Errorf("gits %v", info.getGitNames())
+	}
+}
+
+func TestFakeRunnerIsDir(t *testing.T) {
+	fake := NewFakeRunner()
+	if !runnerIsDir(fake, t.TempDir()) {
+		t.Error("without AddDir the file system is used")
+	}
+	fake.AddDir("/fake/dir/")
+	if !runnerIsDir(fake, "/fake/dir") || runnerIsDir(fake, t.TempDir()) {
+		t.Error("with AddDir only the added directories exist")
+	}
+	// Wrapping Runners don't hide the FakeRunner.
+	wrapped := &toolchainRunner{Runner: &planRunner{Runner: fake, Plan: NewPlan()}, Toolchain: &Toolchain{}}
+	if !runnerIsDir(wrapped, "/fake/dir") {
+		t.Error("wrapped FakeRunner is not used")
+	}
+}
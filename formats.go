@@ -0,0 +1,245 @@
+package gogetvers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Output formats for Generate.
+const (
+	FormatGo     = "go"     // Go source; the default.
+	FormatJSON   = "json"   // JSON document.
+	FormatEnv    = "env"    // Shell variable assignments.
+	FormatMake   = "make"   // Makefile include.
+	FormatHeader = "header" // C header.
+)
+
+// Default output file names for each format.
+var formatFileNames = map[string]string{
+	FormatGo:     "generated_gogetvers.go",
+	FormatJSON:   "gogetvers.json",
+	FormatEnv:    "gogetvers.env",
+	FormatMake:   "gogetvers.mk",
+	FormatHeader: "gogetvers.h",
+}
+
+// Returns the default Generate output file name for format or an empty
+// string if format is unknown.
+func GenerateFileName(format string) string {
+	if format == "" {
+		format = FormatGo
+	}
+	return formatFileNames[format]
+}
+
+// VersionData is the version information for a git; it has the same
+// members as the type made by Generate and is the JSON format's output.
+type VersionData struct {
+	Name            string `json:",omitempty"` // Empty for the package itself.
+	Version         string
+	Hash            string
+	Branch          string
+	OriginUrl       string
+	Status          string
+	Dirty           bool
+	Tag             string
+	Semantic        bool
+	Major           int
+	Minor           int
+	Patch           int
+	Prerelease      string
+	CommitsSinceTag int
+	ShortHash       string
+	ManifestTime    *time.Time     `json:",omitempty"`
+	Dependencies    []*VersionData `json:",omitempty"`
+}
+
+// Creates the VersionData for git.
+func newVersionData(git *Git) (*VersionData, error) {
+	if git == nil {
+		return nil, errors.New("no git information")
+	}
+	rv := &VersionData{
+		Version:   git.Describe,
+		Hash:      git.Hash,
+		Branch:    git.Branch,
+		OriginUrl: git.OriginUrl,
+		Status:    git.Status,
+		Dirty:     git.Dirty()}
+	describe := git.DescribeParts()
+	rv.Tag, rv.CommitsSinceTag, rv.ShortHash = describe.Tag, describe.Commits, describe.Hash
+	if describe.SemVer != nil {
+		rv.Semantic = true
+		rv.Major, rv.Minor, rv.Patch = describe.SemVer.Major, describe.SemVer.Minor, describe.SemVer.Patch
+		rv.Prerelease = describe.SemVer.Prerelease
+	}
+	return rv, nil
+}
+
+// Returns the VersionData for the package with its dependencies; it is an
+// error if the manifest has no git for the package.
+func (td *TemplateData) VersionData() (*VersionData, error) {
+	rv, err := newVersionData(td.Git)
+	if err != nil {
+		return nil, err
+	}
+	rv.Dirty = td.Dirty
+	if !td.Created.IsZero() {
+		created := td.Created
+		rv.ManifestTime = &created
+	}
+	rv.Dependencies = []*VersionData{}
+	for _, git := range td.Gits {
+		dep, err := newVersionData(git)
+		if err != nil {
+			return nil, err
+		}
+		dep.Name = git.HomeDir
+		rv.Dependencies = append(rv.Dependencies, dep)
+	}
+	return rv, nil
+}
+
+// A name and value for the formats that flatten version information.
+type TemplateVariable struct {
+	Name    string // Upper case name, e.g. VERSION_INFO_HASH.
+	Value   string
+	Numeric bool // True if Value is an integer.
+}
+
+// Returns the version information of the package as variables named
+// with the upper case form of VarName as a prefix.
+func (td *TemplateData) Variables() ([]TemplateVariable, error) {
+	vd, err := td.VersionData()
+	if err != nil {
+		return nil, err
+	}
+	prefix := upperSnakeCase(td.VarName) + "_"
+	deps := []string{}
+	for _, dep := range vd.Dependencies {
+		deps = append(deps, dep.Name+"="+dep.Version)
+	}
+	manifestTime := ""
+	if vd.ManifestTime != nil {
+		manifestTime = vd.ManifestTime.Format(time.RFC3339)
+	}
+	dirty := "0"
+	if vd.Dirty {
+		dirty = "1"
+	}
+	rv := []TemplateVariable{
+		{"VERSION", vd.Version, false},
+		{"HASH", vd.Hash, false},
+		{"BRANCH", vd.Branch, false},
+		{"ORIGIN_URL", vd.OriginUrl, false},
+		{"DIRTY", dirty, true},
+		{"MANIFEST_TIME", manifestTime, false},
+		{"TAG", vd.Tag, false},
+		{"MAJOR", strconv.Itoa(vd.Major), true},
+		{"MINOR", strconv.Itoa(vd.Minor), true},
+		{"PATCH", strconv.Itoa(vd.Patch), true},
+		{"PRERELEASE", vd.Prerelease, false},
+		{"COMMITS_SINCE_TAG", strconv.Itoa(vd.CommitsSinceTag), true},
+		{"SHORT_HASH", vd.ShortHash, false},
+		{"DEPENDENCIES", strings.Join(deps, ","), false}}
+	for k := range rv {
+		rv[k].Name = prefix + rv[k].Name
+	}
+	return rv, nil
+}
+
+// Converts a Go style identifier such as VersionInfo to VERSION_INFO.
+func upperSnakeCase(name string) string {
+	rv := []rune{}
+	runes := []rune(name)
+	for k, r := range runes {
+		if k > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[k-1]) || (k+1 < len(runes) && unicode.IsLower(runes[k+1]))) {
+			rv = append(rv, '_')
+		}
+		if r == '-' || r == '.' {
+			r = '_'
+		}
+		rv = append(rv, unicode.ToUpper(r))
+	}
+	return string(rv)
+}
+
+// Quotes str for a POSIX shell.
+func shellQuote(str string) string {
+	return "'" + strings.Replace(str, "'", `'\''`, -1) + "'"
+}
+
+// Escapes str for the right hand side of a Makefile assignment.
+func makeQuote(str string) string {
+	str = strings.Replace(str, "$", "$$", -1)
+	str = strings.Replace(str, "#", `\#`, -1)
+	return strings.Replace(str, "\n", " ", -1)
+}
+
+// Quotes str as a C string literal.
+func cQuote(str string) string {
+	rv := `"`
+	for _, b := range []byte(str) {
+		switch {
+		case b == '"' || b == '\\':
+			rv = rv + `\` + string(b)
+		case b == '\n':
+			rv = rv + `\n`
+		case b < 0x20 || b >= 0x7f:
+			rv = rv + fmt.Sprintf(`\%03o`, b)
+		default:
+			rv = rv + string(b)
+		}
+	}
+	return rv + `"`
+}
+
+// Returns v as indented JSON.
+func jsonString(v interface{}) (string, error) {
+	rv, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(rv) + "\n", nil
+}
+
+// Returns the built in template for format.
+func formatTemplate(format string) (string, error) {
+	switch format {
+	case "", FormatGo:
+		return versionTemplate, nil
+	case FormatJSON:
+		return jsonTemplate, nil
+	case FormatEnv:
+		return envTemplate, nil
+	case FormatMake:
+		return makeTemplate, nil
+	case FormatHeader:
+		return headerTemplate, nil
+	}
+	return "", errors.New(fmt.Sprintf("unknown format: %v", format))
+}
+
+const jsonTemplate = `{{json .VersionData}}`
+
+const envTemplate = `# Code generated by gogetvers; DO NOT EDIT.
+{{range .Variables}}{{.Name}}={{shquote .Value}}
+{{end}}`
+
+const makeTemplate = `# Code generated by gogetvers; DO NOT EDIT.
+{{range .Variables}}{{.Name}} := {{makequote .Value}}
+{{end}}`
+
+const headerTemplate = `/* Code generated by gogetvers; DO NOT EDIT. */
+#ifndef {{upper .VarName}}_H
+#define {{upper .VarName}}_H
+{{range .Variables}}
+#define {{.Name}} {{if .Numeric}}{{.Value}}{{else}}{{cquote .Value}}{{end}}{{end}}
+
+#endif
+`
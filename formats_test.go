@@ -0,0 +1,41 @@
+package gogetvers
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatsWithoutGit(t *testing.T) {
+	data := newTemplateData(NewPackageInfo("example.com/proj", ""), "proj")
+	if _, err := data.VersionData(); err == nil {
+		t.Error("VersionData without a git does not fail")
+	}
+	for _, format := range []string{FormatJSON, FormatEnv, FormatMake, FormatHeader} {
+		if _, err := executeTemplate("", format, data); err == nil {
+			t.Errorf("%v without a git does not fail", format)
+		}
+	}
+	file := filepath.Join(t.TempDir(), "gogetvers.manifest")
+	if err := ioutil.WriteFile(file, []byte(`{"PackageDir":"example.com/proj"}`), 0664); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPackageInfoFile(file); err == nil || !strings.Contains(err.Error(), "no git") {
+		t.Errorf("manifest without a git loads; %v", err)
+	}
+}
+
+func TestFormatEnv(t *testing.T) {
+	info := NewPackageInfo("example.com/proj", "")
+	info.Git = &Git{HomeDir: "example.com/proj", Hash: fakeHash, Describe: "1.2.0-3-g0123abcd", Branch: "it's"}
+	source, err := executeTemplate("", FormatEnv, newTemplateData(info, "proj"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"VERSION_INFO_VERSION='1.2.0-3-g0123abcd'\n", "VERSION_INFO_BRANCH='it'\\''s'\n", "VERSION_INFO_COMMITS_SINCE_TAG='3'\n"} {
+		if !strings.Contains(string(source), want) {
+			t.Errorf("env output does not have %q:\n%s", want, source)
+		}
+	}
+}
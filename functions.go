@@ -1,7 +1,10 @@
 package gogetvers
 
 import (
+	"bytes"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 )
 
 // IsFile determines if path is a file; returns true if it is.
@@ -27,3 +30,49 @@ func IsDir(path string) bool {
 func Mkdir(path string, perm os.FileMode) error {
 	return os.MkdirAll(path, perm)
 }
+
+// WriteFileIfChanged writes data to path unless path already contains data;
+// returns true if the file was written.  The file is written to a temporary
+// file in the same directory that is then renamed to path so readers never
+// see a partial file.  New files are created with perm; existing files keep
+// their permissions.
+func WriteFileIfChanged(path string, data []byte, perm os.FileMode) (bool, error) {
+	if IsFile(path) {
+		if !FileDiffers(path, data) {
+			return false, nil
+		}
+		finfo, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+		perm = finfo.Mode().Perm()
+	}
+	fw, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return false, err
+	}
+	// Remove the temporary file if anything goes wrong; after a
+	// successful rename this does nothing.
+	defer os.Remove(fw.Name())
+	_, err = fw.Write(data)
+	if err == nil {
+		err = fw.Chmod(perm)
+	}
+	if closeErr := fw.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return false, err
+	}
+	err = os.Rename(fw.Name(), path)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Returns true if the file at path does not exist or its contents are not data.
+func FileDiffers(path string, data []byte) bool {
+	current, err := ioutil.ReadFile(path)
+	return err != nil || !bytes.Equal(current, data)
+}
@@ -0,0 +1,86 @@
+package gogetvers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Returns the os.Stat of path.
+func statFile(t *testing.T, path string) os.FileInfo {
+	rv, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rv
+}
+
+// Returns the names of the files in dir.
+func dirNames(t *testing.T, dir string) []string {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv := []string{}
+	for _, info := range infos {
+		rv = append(rv, info.Name())
+	}
+	return rv
+}
+
+func TestWriteFileIfChanged(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.txt")
+	if written, err := WriteFileIfChanged(path, []byte("one"), 0640); err != nil || !written {
+		t.Fatalf("new file written %v, %v", written, err)
+	}
+	if mode := statFile(t, path).Mode().Perm(); mode != 0640 {
+		t.Errorf("new file has mode %v; want 0640", mode)
+	}
+	// An existing file keeps its permissions.
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if written, err := WriteFileIfChanged(path, []byte("two"), 0664); err != nil || !written {
+		t.Fatalf("changed file written %v, %v", written, err)
+	}
+	if mode := statFile(t, path).Mode().Perm(); mode != 0600 {
+		t.Errorf("changed file has mode %v; want 0600", mode)
+	}
+	if contents, _ := ioutil.ReadFile(path); string(contents) != "two" {
+		t.Errorf("file contains %q", contents)
+	}
+	if written, err := WriteFileIfChanged(path, []byte("two"), 0664); err != nil || written {
+		t.Errorf("unchanged file written %v, %v", written, err)
+	}
+	if names := dirNames(t, dir); len(names) != 1 {
+		t.Errorf("files left behind: %v", names)
+	}
+}
+
+func TestGenerateTwiceLeavesFile(t *testing.T) {
+	g, _ := newFakeGoGetVers(t)
+	if err := g.Make(); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	gofile := filepath.Join(dir, "generated_gogetvers.go")
+	if err := g.Generate(gofile, "proj"); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(gofile, past, past); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Generate(gofile, "proj"); err != nil {
+		t.Fatal(err)
+	}
+	if mtime := statFile(t, gofile).ModTime(); !mtime.Equal(past) {
+		t.Errorf("second generate changed the modification time to %v", mtime)
+	}
+	if names := dirNames(t, dir); len(names) != 1 {
+		t.Errorf("files left behind: %v", names)
+	}
+}
@@ -15,6 +15,7 @@ type Git struct {
 	OriginUrl string
 	Describe  string
 	Status    string
+	Runner    Runner `json:"-"` // Runs git commands; DefaultRunner if nil.
 	//
 	*PathsComposite
 }
@@ -22,47 +23,65 @@ type Git struct {
 // FindGitDir starts at path and works upwards looking for .git directory.
 // Stops when it reaches stopDir and returns an error.
 func FindGitDir(path, stopDir string) (string, error) {
-	if path == "" || !IsDir(path) {
+	return FindGitDirWithRunner(path, stopDir, nil)
+}
+
+// Like FindGitDir() but directories are looked up with runner if it is
+// a DirChecker.
+func FindGitDirWithRunner(path, stopDir string, runner Runner) (string, error) {
+	if path == "" || !runnerIsDir(runner, path) {
 		return "", errors.New(fmt.Sprintf("Not a path @ %v", path))
 	}
-	if stopDir == "" || !IsDir(stopDir) {
+	if stopDir == "" || !runnerIsDir(runner, stopDir) {
 		return "", errors.New(fmt.Sprintf("Not a path @ %v", stopDir))
 	}
 	if stopDir == path {
 		return "", errors.New(fmt.Sprintf("Search for git reached stopDir"))
 	}
 	try := filepath.Join(path, ".git")
-	if IsDir(try) {
+	if runnerIsDir(runner, try) {
 		abs, err := filepath.Abs(try)
 		if err != nil {
 			return "", err
 		}
 		return abs, nil
 	}
-	return FindGitDir(filepath.Dir(path), stopDir)
+	return FindGitDirWithRunner(filepath.Dir(path), stopDir, runner)
 }
 
 // NewGitByFind starts at path and look upwards for a .git directory, stopping
 // if stopDir is reached.  Return a git type from the found
 // .git directory.
 func NewGitByFind(path, stopDir string) (*Git, error) {
-	gitDir, err := FindGitDir(path, stopDir)
+	return NewGitByFindWithRunner(path, stopDir, nil)
+}
+
+// Like NewGitByFind() but git commands are run with runner.
+func NewGitByFindWithRunner(path, stopDir string, runner Runner) (*Git, error) {
+	gitDir, err := FindGitDirWithRunner(path, stopDir, runner)
 	if err != nil {
 		return nil, err
 	}
-	return NewGit(filepath.Dir(gitDir))
+	return NewGitWithRunner(filepath.Dir(gitDir), runner)
 }
 
 // Returns a new Git structure for the given path.
-func NewGit(path string) (rv *Git, rverr error) {
-	if !IsDir(path) {
+func NewGit(path string) (*Git, error) {
+	return NewGitWithRunner(path, nil)
+}
+
+// Like NewGit() but git commands are run with runner, and directories
+// are looked up with it if it is a DirChecker; runner is kept by the
+// returned Git.
+func NewGitWithRunner(path string, runner Runner) (rv *Git, rverr error) {
+	if !runnerIsDir(runner, path) {
 		return nil, errors.New(fmt.Sprintf("not a path @ %v", path))
 	}
-	if !IsDir(filepath.Join(path, ".git")) {
+	if !runnerIsDir(runner, filepath.Join(path, ".git")) {
 		return nil, errors.New(fmt.Sprintf("path is not a git @ %v", path))
 	}
 	//
-	rv = &Git{HomeDir: path}
+	rv = &Git{HomeDir: path, Runner: runner}
 	rv.SetPathsComposite()
 	type tempIterator struct {
 		command *Command
@@ -76,7 +95,7 @@ func NewGit(path string) (rv *Git, rverr error) {
 		tempIterator{NewCommandGitDescribe(), &rv.Describe}}
 	//
 	for _, cmd := range commands {
-		err := cmd.command.Exec(path)
+		err := rv.runner().Run(cmd.command, path)
 		if err == nil {
 			*cmd.target = cmd.command.Output
 		}
@@ -106,6 +125,11 @@ func (g *Git) SetPathsComposite() {
 	}
 }
 
+// Returns the Runner for git commands.
+func (g *Git) runner() Runner {
+	return getRunner(g.Runner)
+}
+
 // Clones the git.
 func (g *Git) Clone(mkdirs bool) error {
 	if g == nil {
@@ -113,42 +137,76 @@ func (g *Git) Clone(mkdirs bool) error {
 	}
 	var err error
 	parentDir := filepath.Dir(g.HomeDir)
-	if !IsDir(parentDir) && mkdirs {
-		err = Mkdir(parentDir, 0770)
-		if err != nil {
-			return err
+	plan := getPlan(g.runner())
+	if !runnerIsDir(g.Runner, parentDir) && mkdirs {
+		if plan != nil {
+			plan.AddFile("mkdir", parentDir)
+		} else {
+			err = Mkdir(parentDir, 0770)
+			if err != nil {
+				return err
+			}
 		}
 	}
-	if !IsDir(parentDir) {
+	if !runnerIsDir(g.Runner, parentDir) && !(plan != nil && mkdirs) {
 		err = errors.New(fmt.Sprintf("Not a dir @ %v", parentDir))
 		return err
 	}
 	cmd := NewCommandGitClone("master", g.OriginUrl, filepath.Base(g.HomeDir))
-	err = cmd.Exec(parentDir)
+	err = g.runner().Run(cmd, parentDir)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// Checksout the git to the proper hash.
+// Checksout the git to the proper hash; or to the tag in Describe if
+// there is no hash, see DescribeParts().
 func (g *Git) Checkout() error {
 	if g == nil {
 		return errors.New("nil receiver")
 	}
 	var err error
-	if !IsDir(g.HomeDir) {
+	// A dry run may have planned to clone into HomeDir.
+	if !runnerIsDir(g.Runner, g.HomeDir) && getPlan(g.runner()) == nil {
 		err = errors.New(fmt.Sprintf("Not a dir @ %v", g.HomeDir))
 		return err
 	}
-	cmd := NewCommandGitCheckout(g.Hash)
-	err = cmd.Exec(g.HomeDir)
+	ref := g.Hash
+	if ref == "" {
+		ref = g.Describe
+	}
+	if ref == "" {
+		return errors.New(fmt.Sprintf("no hash or tag to checkout @ %v", g.HomeDir))
+	}
+	cmd := NewCommandGitCheckout(ref)
+	err = g.runner().Run(cmd, g.HomeDir)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// Returns Describe parsed into its parts; the parts are empty if it can't
+// be parsed.  A git with a Describe but no Hash is one that Release
+// described by its tag before the tag was made, so Describe is the tag.
+func (g *Git) DescribeParts() *Describe {
+	if g == nil {
+		return &Describe{}
+	}
+	if g.Hash == "" && g.Describe != "" {
+		rv := &Describe{Tag: g.Describe}
+		rv.SemVer, _ = ParseSemVer(g.Describe)
+		return rv
+	}
+	return templateDescribe(g.Describe)
+}
+
+// Returns true if the git has local modifications.
+func (g *Git) Dirty() bool {
+	return g != nil && g.Status != ""
+}
+
 // Returns git as a string.
 func (g *Git) String() string {
 	if g == nil {
@@ -0,0 +1,97 @@
+package gogetvers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GoGenerateEnv is the environment 'go generate' sets for the commands
+// it runs.
+type GoGenerateEnv struct {
+	File    string // Base name of the file with the directive; from GOFILE.
+	Package string // Name of the package of File; from GOPACKAGE.
+	Dir     string // Directory of File; the working directory of the command.
+}
+
+// Returns the 'go generate' environment or nil if the process was not
+// started by 'go generate'.
+func GetGoGenerateEnv() *GoGenerateEnv {
+	rv := &GoGenerateEnv{
+		File:    os.Getenv("GOFILE"),
+		Package: os.Getenv("GOPACKAGE")}
+	if rv.File == "" || rv.Package == "" {
+		return nil
+	}
+	abs, err := filepath.Abs(rv.File)
+	if err != nil {
+		return nil
+	}
+	rv.Dir = filepath.Dir(abs)
+	return rv
+}
+
+// Inserts directive, a //go:generate line, after the package clause of
+// the go source file; nothing is done if file already has a //go:generate
+// line that runs gogetvers.
+func (g *GoGetVers) InsertGoGenerate(file, directive string) error {
+	if g == nil {
+		return errors.New("nil receiver")
+	}
+	g.Status.Printf("Inserting %v into %v\n", directive, file)
+	if !IsFile(file) {
+		err := errors.New(fmt.Sprintf("Not a file @ %v", file))
+		g.Status.Error(err)
+		return err
+	}
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		g.Status.Error(err)
+		return err
+	}
+	for _, line := range strings.Split(string(contents), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "//go:generate gogetvers") {
+			g.Status.Writeln("Directive already exists: " + strings.TrimSpace(line))
+			return nil
+		}
+	}
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, file, contents, parser.PackageClauseOnly)
+	if err != nil {
+		g.Status.Error(err)
+		return err
+	}
+	// Insert on its own line after the line with the package clause with
+	// a blank line after it so it isn't part of the next declaration's
+	// doc comment.
+	offset := fset.Position(parsed.Name.End()).Offset
+	if k := bytes.IndexByte(contents[offset:], '\n'); k >= 0 {
+		offset = offset + k
+	} else {
+		offset = len(contents)
+	}
+	rest := contents[offset:]
+	rv := append([]byte{}, contents[:offset]...)
+	rv = append(rv, []byte("\n\n"+directive+"\n")...)
+	if len(bytes.TrimSpace(rest)) > 0 && !bytes.HasPrefix(rest, []byte("\n\n")) {
+		rv = append(rv, '\n')
+	}
+	rv = append(rv, bytes.TrimPrefix(rest, []byte("\n"))...)
+	if g.Plan != nil {
+		g.Plan.AddFile("write", file)
+		return nil
+	}
+	_, err = WriteFileIfChanged(file, rv, 0664)
+	if err != nil {
+		g.Status.Error(err)
+		return err
+	}
+	g.Status.Writeln("done")
+	return nil
+}
@@ -0,0 +1,39 @@
+package gogetvers
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestInsertGoGenerate(t *testing.T) {
+	const directive = "//go:generate gogetvers generate"
+	tests := []struct {
+		contents string
+		want     string
+	}{
+		{"package main\n\nfunc main() {}\n", "package main\n\n" + directive + "\n\nfunc main() {}\n"},
+		{"package main\n// Runs.\nfunc main() {}\n", "package main\n\n" + directive + "\n\n// Runs.\nfunc main() {}\n"},
+		{"// Doc.\npackage main // Comment.\n\nimport \"fmt\"\n", "// Doc.\npackage main // Comment.\n\n" + directive + "\n\nimport \"fmt\"\n"},
+		{"package main\n", "package main\n\n" + directive + "\n"},
+		{"package main", "package main\n\n" + directive + "\n"},
+		{"package main\n\n" + directive + " -g x.go\n", "package main\n\n" + directive + " -g x.go\n"},
+	}
+	g := &GoGetVers{}
+	for _, test := range tests {
+		file := filepath.Join(t.TempDir(), "main.go")
+		if err := ioutil.WriteFile(file, []byte(test.contents), 0664); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.InsertGoGenerate(file, directive); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("inserted into %q is %q; want %q", test.contents, got, test.want)
+		}
+	}
+}
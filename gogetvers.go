@@ -1,11 +1,9 @@
 package gogetvers
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"path/filepath"
 	"strings"
 )
@@ -17,6 +15,69 @@ type GoGetVers struct {
 	File        string        // Path of package info file.
 	PackageInfo *PackageInfo  // The package info
 	Status      *StatusWriter // The status writer.
+	Runner      Runner        // Runs git and go commands.
+	Plan        *Plan         // If non-nil then dry run; changes are recorded here instead of performed.
+	Retry       *RetryPolicy  // If non-nil then failing network commands are retried.
+	Toolchain   *Toolchain    // If non-nil then the binaries and environment for commands.
+	Hooks       *Hooks        // If non-nil then commands run before and after operations.
+	//
+	GenerateOptions GenerateOptions // Options for Generate.
+	ReleaseOptions  ReleaseOptions  // Options for Release.
+	VerifyOptions   VerifyOptions   // Options for the checks before Release and Tag.
+	TagOptions      TagOptions      // Options for Tag.
+}
+
+// Options for Generate.
+type GenerateOptions struct {
+	Template string // If not empty then the text/template file used instead of the built in template.
+	Format   string // Output format; one of the Format constants, FormatGo if empty.
+	HTTP     bool   // If true then the Go output is an http.Handler and is published with expvar.
+	VarName  string // Name of the generated variable; VersionInfo if empty.
+	TypeName string // Name of the generated type; VarName with a Type suffix if empty.
+	// Build constraint for the Go output, e.g. "linux && !race"; none if empty.
+	BuildConstraint string
+	// If true then the compressed manifest is embedded in the Go output.
+	Embed bool
+}
+
+// Options for Release.
+type ReleaseOptions struct {
+	// If true then the manifest and generated file are made for the new
+	// tag and committed before the tag is created, so the tag is on the
+	// commit that contains them.  Otherwise the tag is created and pushed
+	// first and the manifest and generated file are committed after it.
+	CommitFirst bool
+	// If true then every dependency must be at a tagged commit, i.e. its
+	// describe has a tag and no commits past it, unless it is allowed by
+	// AllowUntagged.
+	RequireTagged bool
+	// Names of dependencies, e.g. github.com/user/repo, that may be
+	// untagged when RequireTagged is set; path.Match patterns such as
+	// github.com/user/* are allowed.
+	AllowUntagged []string
+	// The remote the tag is pushed to; "origin" if empty.
+	Remote string
+	// If true then the current branch is pushed to the branch it tracks
+	// on Remote, or one of the same name, along with the tag so the
+	// release commit is published.
+	PushBranch bool
+	// If true then the current branch must not be behind its upstream,
+	// which must be on Remote, after fetching Remote, before anything is
+	// done.
+	CheckUpstream bool
+}
+
+// Options for Tag.
+type TagOptions struct {
+	// If true then an existing annotated tag or a tag that exists on
+	// Remote is replaced; otherwise Tag refuses to replace them.  An
+	// annotated tag is replaced by one with the same message and tagger.
+	Force bool
+	// If true then the tag is pushed to Remote, replacing it there if
+	// Force is set.
+	Push bool
+	// The remote that is checked for the tag and pushed to; "origin" if empty.
+	Remote string
 }
 
 // Create a new GoGetVers that will have working path 'path' and input/output file 'file.'
@@ -26,65 +87,132 @@ func NewGoGetVers(path, file string, statusWriter io.Writer) (*GoGetVers, error)
 	if err != nil {
 		return nil, err
 	}
-	rv := &GoGetVers{Path: abs, File: file}
+	rv := &GoGetVers{Path: abs, File: file, Runner: DefaultRunner}
 	if statusWriter != nil {
 		rv.Status = &StatusWriter{Writer: statusWriter}
 	}
 	return rv, nil
 }
 
+// Returns the Runner for git and go commands.
+func (g *GoGetVers) runner() Runner {
+	runner := getRunner(g.Runner)
+	if g.Retry != nil {
+		runner = &retryRunner{Runner: runner, Policy: g.Retry, Status: g.Status}
+	}
+	if g.Plan != nil {
+		runner = &planRunner{Runner: runner, Plan: g.Plan}
+	}
+	if g.Toolchain != nil {
+		runner = &toolchainRunner{Runner: runner, Toolchain: g.Toolchain}
+	}
+	return runner
+}
+
+// Applies the settings in config.
+func (g *GoGetVers) ApplyConfig(config *Config) {
+	if g == nil || config == nil {
+		return
+	}
+	if config.Toolchain != nil {
+		g.Toolchain = config.Toolchain
+	}
+	if config.Release != nil {
+		g.ReleaseOptions = *config.Release
+	}
+	if config.Verify != nil {
+		g.VerifyOptions = *config.Verify
+	}
+	if config.Tag != nil {
+		g.TagOptions = *config.Tag
+	}
+	if config.Hooks != nil {
+		g.Hooks = config.Hooks
+	}
+}
+
+// Returns the name of the package at Path according to 'go list'.
+func (g *GoGetVers) PackageName() (string, error) {
+	if g == nil {
+		return "", errors.New("nil receiver")
+	}
+	cmd := NewCommandGoListName()
+	err := g.runner().Run(cmd, g.Path)
+	if err != nil {
+		return "", err
+	}
+	return cmd.Output, nil
+}
+
 // Use package name from manifest file if packageName is empty string.
 func (g *GoGetVers) Generate(outputFile, packageName string) error {
 	if g == nil {
 		return errors.New("nil receiver")
 	}
+	env := []string{HookEnvGoFile + "=" + outputFile, HookEnvPackage + "=" + packageName}
+	return g.withHooks(OperationGenerate, env, func() error {
+		return g.generate(outputFile, packageName)
+	})
+}
+
+// Does the work of Generate without running hooks.
+func (g *GoGetVers) generate(outputFile, packageName string) error {
 	g.Status.Printf("Generating version file from manifest @ %v\n", g.File)
 	g.Status.Printf("Output location @ %v\n", g.Path)
 	//
 	var err error
-	g.PackageInfo, err = LoadPackageInfoFile(g.File)
-	if err != nil {
-		g.Status.Error(err)
-		return err
+	if g.Plan.Writes(g.File) && g.PackageInfo != nil {
+		// The dry run did not write the manifest so use the one in memory.
+		g.Status.Writeln("Using manifest from dry run.")
+	} else {
+		g.PackageInfo, err = LoadPackageInfoFile(g.File)
+		if err != nil {
+			g.Status.Error(err)
+			return err
+		}
+		g.Status.Writeln("Load manifest successful.")
 	}
-	g.Status.Writeln("Load manifest successful.")
 	//
 	if packageName == "" {
 		packageName = filepath.Base(g.PackageInfo.PackageDir)
 	}
-	template := strings.Replace(versionTemplate, "$PACKAGE_NAME", packageName, -1)
-	template = strings.Replace(template, "$VARNAME", "VersionInfo", -1)
-	template = strings.Replace(template, "$TYPE_NAME", "VersionInfoType", -1)
-	template = strings.Replace(template, "$VERSION", fmt.Sprintf("\"%v\"", g.PackageInfo.Git.Describe), -1)
-	deps := []string{}
-	for _, git := range g.PackageInfo.getGits() {
-		deps = append(deps, fmt.Sprintf("{\"%v\",\"%v\"}", git.HomeDir, git.Describe))
+	if g.GenerateOptions.Template != "" {
+		g.Status.Printf("Using template @ %v\n", g.GenerateOptions.Template)
 	}
-	depsString := fmt.Sprintf("{%v}", strings.Join(deps, ",\n"))
-	template = strings.Replace(template, "$DEPENDENCIES", depsString, -1)
-	//
-	fw, err := os.Create(outputFile)
+	format := g.GenerateOptions.Format
+	if format == "" {
+		format = FormatGo
+	}
+	data := newTemplateData(g.PackageInfo, packageName)
+	err = data.applyOptions(g.GenerateOptions)
 	if err != nil {
 		g.Status.Error(err)
 		return err
 	}
-	defer fw.Close()
-	wrote, err := fw.WriteString(template)
+	source, err := executeTemplate(g.GenerateOptions.Template, format, data)
 	if err != nil {
 		g.Status.Error(err)
 		return err
 	}
-	if wrote != len(template) {
-		err = errors.New(fmt.Sprintf("partial file write @ %v", outputFile))
-		g.Status.Error(err)
-		return err
+	if format == FormatGo {
+		source, err = formatGoSource(outputFile, source)
+		if err != nil {
+			g.Status.Error(err)
+			return err
+		}
 	}
 	//
-	cmd := NewCommandGoFmt(filepath.Base(outputFile))
-	err = cmd.Exec(filepath.Dir(outputFile))
-	if err != nil {
-		g.Status.Error(err)
-		return err
+	if !FileDiffers(outputFile, source) {
+		g.Status.Printf("%v is up to date\n", outputFile)
+	} else if g.Plan != nil {
+		g.Plan.AddFile("write", outputFile)
+	} else {
+		_, err = WriteFileIfChanged(outputFile, source, 0664)
+		if err != nil {
+			g.Status.Error(err)
+			return err
+		}
+		g.Status.Printf("Wrote %v\n", outputFile)
 	}
 	//
 	return nil
@@ -95,6 +223,11 @@ func (g *GoGetVers) Checkout() error {
 	if g == nil {
 		return errors.New("nil receiver")
 	}
+	return g.withHooks(OperationCheckout, nil, g.checkout)
+}
+
+// Does the work of Checkout without running hooks.
+func (g *GoGetVers) checkout() error {
 	g.Status.Printf("Attempting to checkout manifest @ %v\n", g.File)
 	g.Status.Printf("Output location @ %v\n", g.Path)
 	//
@@ -106,10 +239,11 @@ func (g *GoGetVers) Checkout() error {
 	}
 	g.Status.Writeln("Load manifest successful.")
 	//
-	if !IsDir(g.Path) {
+	if !runnerIsDir(g.runner(), g.Path) {
 		return errors.New(fmt.Sprintf("not a path @ %v", g.Path))
 	}
 	g.PackageInfo.SetPathPrefix(g.Path)
+	g.PackageInfo.SetRunner(g.runner())
 	// none of g.PackageInfo.gits can have local modifications
 	mods, nomods, dne, err := g.PackageInfo.getGitsLocalModsStatus()
 	if err != nil {
@@ -142,6 +276,11 @@ func (g *GoGetVers) Rebuild() error {
 	if g == nil {
 		return errors.New("nil receiver")
 	}
+	return g.withHooks(OperationRebuild, nil, g.rebuild)
+}
+
+// Does the work of Rebuild without running hooks.
+func (g *GoGetVers) rebuild() error {
 	g.Status.Printf("Attempting to rebuild manifest @ %v\n", g.File)
 	g.Status.Printf("Output location @ %v\n", g.Path)
 	//
@@ -153,10 +292,11 @@ func (g *GoGetVers) Rebuild() error {
 	}
 	g.Status.Writeln("Load manifest successful.")
 	//
-	if !IsDir(g.Path) {
+	if !runnerIsDir(g.runner(), g.Path) {
 		return errors.New(fmt.Sprintf("not a path @ %v", g.Path))
 	}
 	g.PackageInfo.SetPathPrefix(g.Path)
+	g.PackageInfo.SetRunner(g.runner())
 	// Rebuild requires that all gits do not exist.
 	exist, dne := g.PackageInfo.getGitsDiskStatus()
 	if exist.Len() > 0 {
@@ -175,78 +315,88 @@ func (g *GoGetVers) Rebuild() error {
 	return nil
 }
 
-// Simplifies creating and tagging a production release of a package.
-func (g *GoGetVers) Release(gofile, packageName, tag, message string) error {
+// Simplifies tagging of a feature or development branch.  An existing
+// lightweight tag is replaced but an annotated tag, or a tag that exists
+// on the remote, is only replaced if TagOptions.Force is set.
+func (g *GoGetVers) Tag(gofile, packageName, tag string) error {
 	if g == nil {
 		return errors.New("nil receiver")
 	}
-	var err error
+	env := []string{HookEnvGoFile + "=" + gofile, HookEnvPackage + "=" + packageName, HookEnvTag + "=" + tag}
+	return g.withHooks(OperationTag, env, func() error {
+		return g.tag(gofile, packageName, tag)
+	})
+}
+
+// Does the work of Tag without running hooks.
+func (g *GoGetVers) tag(gofile, packageName, tag string) error {
 	//
 	if tag == "" {
 		return errors.New("tag is empty")
 	}
-	// Get package information because we need to check for local modifications.
-	g.PackageInfo, err = getPackageInfo(g.Path, nil)
-	if err != nil {
-		g.Status.Error(err)
-		return err
-	}
 	//
-	gits := g.PackageInfo.getGits()
-	gitsWMods := []string{}
-	for _, git := range gits {
-		if git.Status != "" {
-			gitsWMods = append(gitsWMods, git.HomeDir)
+	var err error
+	//
+	if g.VerifyOptions.Tag {
+		err = g.Verify()
+		if err != nil {
+			return err
 		}
 	}
-	if len(gitsWMods) > 0 {
-		err := errors.New("The following repositories have local modifications: " + strings.Join(gitsWMods, ", "))
-		g.Status.Error(err)
-		return err
-
-	}
 	//
-	if message == "" {
-		message = tag
+	remote := g.TagOptions.Remote
+	if remote == "" {
+		remote = DefaultRemote
 	}
-	gittag := NewCommandGitTagAnnotated(tag, message)
-	g.Status.Writeln(gittag.String())
-	err = gittag.Exec(g.Path)
+	exists, annotation, err := g.checkTag(tag, remote)
 	if err != nil {
 		g.Status.Error(err)
 		return err
 	}
 	//
-	gittagpush := NewCommandGitTagPush(tag, "origin")
-	g.Status.Writeln(gittagpush.String())
-	err = gittagpush.Exec(g.Path)
-	if err != nil {
-		g.Status.Error(err)
-		return err
+	if exists {
+		gittagdel := NewCommandGitTagDelete(tag)
+		g.Status.Writeln(gittagdel.String())
+		err = g.runner().Run(gittagdel, g.Path)
+		if err != nil {
+			g.Status.Error(err)
+			return err
+		}
 	}
 	//
-	err = g.Make()
+	gittag := NewCommandGitTag(tag)
+	if annotation != nil {
+		// A forced annotated tag keeps its message and tagger.
+		gittag = NewCommandGitTagAnnotated(tag, annotation.Message)
+		gittag.Env = annotation.Env
+	}
+	g.Status.Writeln(gittag.String())
+	err = g.runner().Run(gittag, g.Path)
 	if err != nil {
 		g.Status.Error(err)
 		return err
 	}
 	//
-	err = g.Generate(gofile, packageName)
-	if err != nil {
-		g.Status.Error(err)
-		return err
+	if g.TagOptions.Push {
+		gittagpush := NewCommandGitTagPush(tag, remote)
+		if g.TagOptions.Force {
+			gittagpush = NewCommandGitTagPushForce(tag, remote)
+		}
+		g.Status.Writeln(gittagpush.String())
+		err = g.runner().Run(gittagpush, g.Path)
+		if err != nil {
+			g.Status.Error(err)
+			return err
+		}
 	}
 	//
-	gitadd := NewCommand("git", "add", ".")
-	gitadd.Exec(g.Path)
+	err = g.makeManifest("")
 	if err != nil {
 		g.Status.Error(err)
 		return err
 	}
 	//
-	gitcommit := NewCommand("git", "commit", "-m", message)
-	g.Status.Writeln(gitcommit.String())
-	gitcommit.Exec(g.Path)
+	err = g.generate(gofile, packageName)
 	if err != nil {
 		g.Status.Error(err)
 		return err
@@ -255,54 +405,104 @@ func (g *GoGetVers) Release(gofile, packageName, tag, message string) error {
 	return nil
 }
 
-// Simplifies tagging of a feature or development branch.
-func (g *GoGetVers) Tag(gofile, packageName, tag string) error {
-	if g == nil {
-		return errors.New("nil receiver")
+// The message and tagger of an annotated tag.
+type tagAnnotation struct {
+	Message string
+	Env     []string // GIT_COMMITTER_* variables for the tagger.
+}
+
+// Returns the message and tagger of the annotated tag.
+func (g *GoGetVers) getTagAnnotation(tag string) (*tagAnnotation, error) {
+	gitannotation := NewCommandGitTagAnnotation(tag)
+	err := g.runner().Run(gitannotation, g.Path)
+	if err != nil {
+		return nil, err
 	}
-	//
-	if tag == "" {
-		return errors.New("tag is empty")
+	pieces := strings.SplitN(gitannotation.Output, "\x00", 4)
+	if len(pieces) != 4 {
+		return nil, errors.New(fmt.Sprintf("can not read annotated tag %v", tag))
 	}
-	//
-	var err error
-	//
-	gittagdel := NewCommandGitTagDelete(tag)
-	gittagdel.Exec(g.Path)
-	//
-	gittag := NewCommandGitTag(tag)
-	err = gittag.Exec(g.Path)
-	if err != nil {
-		g.Status.Error(err)
-		return err
+	rv := &tagAnnotation{Message: pieces[3]}
+	if rv.Message == "" {
+		rv.Message = tag
 	}
-	//
-	err = g.Make()
-	if err != nil {
-		g.Status.Error(err)
-		return err
+	if pieces[0] != "" && pieces[2] != "" {
+		rv.Env = []string{"GIT_COMMITTER_NAME=" + pieces[0], "GIT_COMMITTER_EMAIL=" + strings.Trim(pieces[1], "<>"), "GIT_COMMITTER_DATE=" + pieces[2]}
+	}
+	return rv, nil
+}
+
+// Returns true if tag exists locally and, if it is annotated, its
+// annotation.  Returns an error, unless TagOptions.Force is set, if tag is
+// annotated or exists on remote; the remote is only checked if it is
+// configured and, if the tag isn't pushed, can't be reached.
+func (g *GoGetVers) checkTag(tag, remote string) (bool, *tagAnnotation, error) {
+	exists := false
+	var annotation *tagAnnotation
+	gitcatfile := NewCommandGitCatFileType("refs/tags/" + tag)
+	if g.runner().Run(gitcatfile, g.Path) == nil {
+		exists = true
+		if gitcatfile.Output == "tag" {
+			if !g.TagOptions.Force {
+				return exists, nil, errors.New(fmt.Sprintf("%v is an annotated tag; use force to replace it", tag))
+			}
+			var err error
+			annotation, err = g.getTagAnnotation(tag)
+			if err != nil {
+				return exists, nil, err
+			}
+			g.Status.Warning(fmt.Sprintf("Replacing annotated tag %v", tag))
+		}
 	}
 	//
-	err = g.Generate(gofile, packageName)
+	if g.runner().Run(NewCommandGitRemoteUrl(remote), g.Path) != nil {
+		g.Status.Printf("Remote %v is not configured; not checking it for %v\n", remote, tag)
+		return exists, annotation, nil
+	}
+	gitlsremote := NewCommandGitLsRemote(remote, "refs/tags/"+tag)
+	err := g.runner().Run(gitlsremote, g.Path)
 	if err != nil {
-		g.Status.Error(err)
-		return err
+		err = errors.New(fmt.Sprintf("can not check %v for tag %v: %v", remote, tag, err))
+		if g.TagOptions.Push {
+			return exists, annotation, err
+		}
+		g.Status.Warning(err.Error())
+		return exists, annotation, nil
 	}
-	//
-	return g.Make()
+	if gitlsremote.Output != "" {
+		if !g.TagOptions.Force {
+			return exists, annotation, errors.New(fmt.Sprintf("%v exists on %v; use force to replace it", tag, remote))
+		}
+		g.Status.Warning(fmt.Sprintf("Replacing tag %v that exists on %v", tag, remote))
+	}
+	return exists, annotation, nil
 }
 
 // Makes a manifest file for the package.
 func (g *GoGetVers) Make() error {
+	if g == nil {
+		return errors.New("nil receiver")
+	}
+	return g.withHooks(OperationMake, nil, func() error {
+		return g.makeManifest("")
+	})
+}
+
+// Makes a manifest file for the package; if tag is not empty then the
+// package's git is described as if its next commit were tagged with it.
+func (g *GoGetVers) makeManifest(tag string) error {
 	if g == nil {
 		return errors.New("nil receiver")
 	}
 	var err error
-	g.PackageInfo, err = getPackageInfo(g.Path, g.Status)
+	g.PackageInfo, err = getPackageInfo(g.Path, g.runner(), g.Status)
 	if err != nil {
 		g.Status.Error(err)
 		return err
 	}
+	if tag != "" {
+		g.PackageInfo.setReleaseTag(tag)
+	}
 	//
 	g.PackageInfo.StripPathPrefix(g.PackageInfo.RootDir)
 	g.Status.Writeln(g.PackageInfo.getSummary())
@@ -322,22 +522,31 @@ func (g *GoGetVers) Make() error {
 		g.Status.Outdent()
 	}
 	//
-	g.Status.Printf("Writing output to %v\n", g.File)
-	g.Status.Indent()
-	fw, err := os.Create(g.File)
-	if err != nil {
-		g.Status.Error(err)
-		return err
+	// Created only changes along with the rest of the manifest so making
+	// it again when nothing changed leaves the file alone.
+	if old, err := LoadPackageInfoFile(g.File); err == nil && g.PackageInfo.sameContent(old) {
+		g.PackageInfo.Created = old.Created
 	}
-	defer fw.Close()
-	//
-	enc := json.NewEncoder(fw)
-	err = enc.Encode(g.PackageInfo)
+	manifest, err := g.PackageInfo.encode()
 	if err != nil {
 		g.Status.Error(err)
 		return err
 	}
-	g.Status.Writeln("done")
+	g.Status.Printf("Writing output to %v\n", g.File)
+	g.Status.Indent()
+	if !FileDiffers(g.File, manifest) {
+		g.Status.Writeln("up to date")
+	} else if g.Plan != nil {
+		g.Plan.AddFile("write", g.File)
+		g.Status.Writeln("skipped for dry run")
+	} else {
+		_, err = WriteFileIfChanged(g.File, manifest, 0664)
+		if err != nil {
+			g.Status.Error(err)
+			return err
+		}
+		g.Status.Writeln("done")
+	}
 	g.Status.Outdent()
 	g.Status.Writeln("")
 	//
@@ -0,0 +1,132 @@
+package gogetvers
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Operations that hooks can be run around.
+const (
+	OperationCheckout = "checkout"
+	OperationGenerate = "generate"
+	OperationMake     = "make"
+	OperationRebuild  = "rebuild"
+	OperationRelease  = "release"
+	OperationTag      = "tag"
+)
+
+// Environment variables set for hook commands.
+const (
+	HookEnvOperation = "GOGETVERS_OPERATION" // The operation, e.g. release.
+	HookEnvStage     = "GOGETVERS_STAGE"     // pre or post.
+	HookEnvPath      = "GOGETVERS_PATH"      // Working path of gogetvers.
+	HookEnvManifest  = "GOGETVERS_MANIFEST"  // Path of the manifest file.
+	HookEnvGoFile    = "GOGETVERS_GOFILE"    // Generated file; generate, release and tag.
+	HookEnvPackage   = "GOGETVERS_PACKAGE"   // Package name; generate, release and tag.
+	HookEnvTag       = "GOGETVERS_TAG"       // The tag; release and tag.
+	HookEnvMessage   = "GOGETVERS_MESSAGE"   // The tag message; release.
+	HookEnvVersion   = "GOGETVERS_VERSION"   // Describe of the package's git, if known.
+	HookEnvHash      = "GOGETVERS_HASH"      // Commit hash of the package's git, if known.
+)
+
+// Hooks are commands run in Path before and after operations.  Each is
+// keyed by one of the Operation constants and is a list of commands where
+// a command is a binary and its arguments.  A failing pre hook aborts the
+// operation; post hooks run only if the operation succeeds.  Operations
+// run by other operations, e.g. make by release, don't run their hooks.
+type Hooks struct {
+	Pre  map[string][][]string
+	Post map[string][][]string
+}
+
+// Returns an error if any hook is for an unknown operation or is empty.
+func (h *Hooks) validate() error {
+	if h == nil {
+		return nil
+	}
+	known := map[string]bool{
+		OperationCheckout: true,
+		OperationGenerate: true,
+		OperationMake:     true,
+		OperationRebuild:  true,
+		OperationRelease:  true,
+		OperationTag:      true}
+	for stage, hooks := range map[string]map[string][][]string{"Pre": h.Pre, "Post": h.Post} {
+		for operation, commands := range hooks {
+			if !known[operation] {
+				return errors.New(fmt.Sprintf("unknown operation for %v hook: %v", stage, operation))
+			}
+			for _, args := range commands {
+				if len(args) == 0 {
+					return errors.New(fmt.Sprintf("empty %v hook for %v", stage, operation))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Runs the hooks of stage, "pre" or "post", for operation with env added
+// to the environment describing the operation.
+func (g *GoGetVers) runHooks(stage, operation string, env []string) error {
+	var commands [][]string
+	if g.Hooks != nil && stage == "pre" {
+		commands = g.Hooks.Pre[operation]
+	} else if g.Hooks != nil {
+		commands = g.Hooks.Post[operation]
+	}
+	if len(commands) == 0 {
+		return nil
+	}
+	// Hooks set directly, rather than by a config, aren't validated.
+	for _, args := range commands {
+		if len(args) == 0 {
+			err := errors.New(fmt.Sprintf("empty %v-%v hook", stage, operation))
+			g.Status.Error(err)
+			return err
+		}
+	}
+	env = append([]string{
+		HookEnvOperation + "=" + operation,
+		HookEnvStage + "=" + stage,
+		HookEnvPath + "=" + g.Path,
+		HookEnvManifest + "=" + g.File}, env...)
+	if g.PackageInfo != nil && g.PackageInfo.Git != nil {
+		env = append(env, HookEnvVersion+"="+g.PackageInfo.Git.Describe, HookEnvHash+"="+g.PackageInfo.Git.Hash)
+	}
+	g.Status.Printf("Running %v-%v hooks\n", stage, operation)
+	g.Status.Indent()
+	defer g.Status.Outdent()
+	for _, args := range commands {
+		cmd := NewCommandUser(args)
+		cmd.Env = env
+		g.Status.Writeln(cmd.String())
+		err := g.runner().Run(cmd, g.Path)
+		g.Status.WriteOutput(cmd)
+		if err != nil {
+			err = errors.New(fmt.Sprintf("%v-%v hook failed: %v", stage, operation, err))
+			g.Status.Error(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// Runs fn, the work of operation, between its pre and post hooks; fn is
+// not run if a pre hook fails.  The error for a failing post hook says
+// that operation succeeded.
+func (g *GoGetVers) withHooks(operation string, env []string, fn func() error) error {
+	err := g.runHooks("pre", operation, env)
+	if err != nil {
+		return err
+	}
+	err = fn()
+	if err != nil {
+		return err
+	}
+	err = g.runHooks("post", operation, env)
+	if err != nil {
+		return errors.New(fmt.Sprintf("%v succeeded but %v", operation, err))
+	}
+	return nil
+}
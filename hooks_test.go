@@ -0,0 +1,39 @@
+package gogetvers
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreHookFailureAborts(t *testing.T) {
+	g, fake := newFakeGoGetVers(t)
+	fake.Default = &FakeResult{}
+	fake.Script(fakePackage, "./check.sh", "not ready", 1)
+	g.Hooks = &Hooks{Pre: map[string][][]string{OperationRelease: {{"./check.sh"}}}, Post: map[string][][]string{OperationRelease: {{"./notify.sh"}}}}
+	err := g.Release(filepath.Join(t.TempDir(), "generated_gogetvers.go"), "proj", "1.3.0", "")
+	if err == nil || !strings.Contains(err.Error(), "pre-release hook failed") {
+		t.Fatalf("failing pre hook returned %v", err)
+	}
+	if calls := fake.Commands(); len(calls) != 1 {
+		t.Errorf("ran %v after the pre hook failed", calls[1:])
+	}
+}
+
+func TestEmptyHookIsAnError(t *testing.T) {
+	g, fake := newFakeGoGetVers(t)
+	for _, hooks := range []*Hooks{
+		{Pre: map[string][][]string{OperationMake: {{}}}},
+		{Post: map[string][][]string{OperationMake: {{"./notify.sh"}, nil}}},
+	} {
+		g.Hooks = hooks
+		if err := g.Make(); err == nil || !strings.Contains(err.Error(), "empty") {
+			t.Errorf("%+v returned %v", hooks, err)
+		}
+	}
+	for _, call := range fake.Commands() {
+		if call == "./notify.sh" {
+			t.Error("a hook ran before the empty one was found")
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package gogetvers
+
+import (
+	"debug/buildinfo"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BinaryModule is a module recorded in the build information of a binary.
+type BinaryModule struct {
+	Path    string
+	Version string
+}
+
+// BinaryInfo is the version information found in a compiled Go binary.
+type BinaryInfo struct {
+	Binary    string            // Path of the binary.
+	GoVersion string            // Go version the binary was built with, e.g. go1.22.1.
+	Path      string            // Import path of the main package.
+	Module    BinaryModule      // Main module; empty for GOPATH builds.
+	Revision  string            // Commit hash from vcs.revision; empty if not stamped.
+	Modified  bool              // True if vcs.modified is set.
+	Ldflags   map[string]string // Values set with -ldflags -X keyed by importpath.name.
+	Deps      []BinaryModule    // Module dependencies.
+	Version   *VersionData      // Version information from the file made by generate; nil if none.
+	Manifest  *PackageInfo      // Manifest embedded by 'generate --embed'; nil if none.
+}
+
+// Reads the build information, the version information from the file made
+// by Generate and the embedded manifest of the Go binary.
+func InspectBinary(binary string) (*BinaryInfo, error) {
+	if !IsFile(binary) {
+		return nil, errors.New(fmt.Sprintf("Not a file @ %v", binary))
+	}
+	bi, err := buildinfo.ReadFile(binary)
+	if err != nil {
+		return nil, err
+	}
+	rv := &BinaryInfo{
+		Binary:    binary,
+		GoVersion: bi.GoVersion,
+		Path:      bi.Path,
+		Module:    BinaryModule{Path: bi.Main.Path, Version: bi.Main.Version},
+		Ldflags:   make(map[string]string)}
+	for _, dep := range bi.Deps {
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		rv.Deps = append(rv.Deps, BinaryModule{Path: dep.Path, Version: dep.Version})
+	}
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			rv.Revision = setting.Value
+		case "vcs.modified":
+			rv.Modified = setting.Value == "true"
+		case "-ldflags":
+			rv.Ldflags = parseLdflagsX(setting.Value)
+		}
+	}
+	// The version information and manifest are optional.
+	rv.Version, _ = ExtractVersionData(binary)
+	if manifest, err := ExtractManifest(binary); err == nil {
+		rv.Manifest = &PackageInfo{}
+		if err = json.Unmarshal(manifest, rv.Manifest); err != nil {
+			return nil, err
+		}
+	}
+	return rv, nil
+}
+
+// Returns the -X importpath.name=value settings in ldflags.
+func parseLdflagsX(ldflags string) map[string]string {
+	rv := make(map[string]string)
+	fields := SplitQuoted(ldflags)
+	for k := 0; k < len(fields); k++ {
+		setting := ""
+		if fields[k] == "-X" && k+1 < len(fields) {
+			setting = fields[k+1]
+			k++
+		} else if strings.HasPrefix(fields[k], "-X=") {
+			setting = strings.TrimPrefix(fields[k], "-X=")
+		}
+		if n := strings.Index(setting, "="); n > 0 {
+			rv[setting[:n]] = setting[n+1:]
+		}
+	}
+	return rv
+}
+
+// Splits str into fields as go build splits -ldflags: on white space,
+// except that a field starting with ' or " ends at the matching quote.
+// The quotes are removed; there are no escapes.  It is also used to
+// split the commands given to 'gogetvers release --verify-cmd'.
+func SplitQuoted(str string) []string {
+	rv := []string{}
+	for {
+		str = strings.TrimLeft(str, ldflagsSpace)
+		if str == "" {
+			return rv
+		}
+		if str[0] == '\'' || str[0] == '"' {
+			k := strings.IndexByte(str[1:], str[0])
+			if k < 0 {
+				return append(rv, str[1:])
+			}
+			rv = append(rv, str[1:k+1])
+			str = str[k+2:]
+			continue
+		}
+		k := strings.IndexAny(str, ldflagsSpace)
+		if k < 0 {
+			return append(rv, str)
+		}
+		rv = append(rv, str[:k])
+		str = str[k:]
+	}
+}
+
+// Returns the values set with -ldflags -X grouped by package for the
+// packages that were given values by 'gogetvers ldflags'.
+func (b *BinaryInfo) ldflagsPackages() map[string]map[string]string {
+	rv := make(map[string]map[string]string)
+	for key, value := range b.Ldflags {
+		k := strings.LastIndex(key, ".")
+		if k < 0 {
+			continue
+		}
+		if rv[key[:k]] == nil {
+			rv[key[:k]] = make(map[string]string)
+		}
+		rv[key[:k]][key[k+1:]] = value
+	}
+	for pkg, vars := range rv {
+		_, hasHash := vars["Hash"]
+		_, hasDeps := vars["Dependencies"]
+		if !hasHash || !hasDeps {
+			delete(rv, pkg)
+		}
+	}
+	return rv
+}
+
+// Returns the binary information as a string.
+func (b *BinaryInfo) String() string {
+	if b == nil {
+		return ""
+	}
+	rv := "Binary Summary\n"
+	rv = rv + "    binary> " + b.Binary + "\n"
+	rv = rv + "    toolchain> " + b.GoVersion + "\n"
+	rv = rv + "    path> " + b.Path + "\n"
+	if b.Module.Path != "" {
+		rv = rv + "    module> " + b.Module.Path + " " + b.Module.Version + "\n"
+	}
+	if b.Revision != "" {
+		rv = rv + "    revision> " + b.Revision
+		if b.Modified {
+			rv = rv + " (modified)"
+		}
+		rv = rv + "\n"
+	}
+	rv = rv + "    ldflags>\n"
+	keys := []string{}
+	for key := range b.Ldflags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		rv = rv + "        " + key + "=" + b.Ldflags[key] + "\n"
+	}
+	rv = rv + "    modules>\n"
+	for _, dep := range b.Deps {
+		rv = rv + "        " + dep.Path + " " + dep.Version + "\n"
+	}
+	if b.Version == nil {
+		rv = rv + "    version> none; the binary does not use a file made by generate\n"
+	} else {
+		rv = rv + "    version> " + b.Version.Version + " " + b.Version.Hash + "\n"
+		for _, dep := range b.Version.Dependencies {
+			rv = rv + "        " + dep.Name + " " + dep.Version + " " + dep.Hash + "\n"
+		}
+	}
+	if b.Manifest == nil {
+		rv = rv + "    manifest> none; made with generate --embed to include it\n"
+		return rv
+	}
+	rv = rv + "    manifest> embedded\n"
+	if !b.Manifest.Created.IsZero() {
+		rv = rv + "        created> " + b.Manifest.Created.Format(time.RFC3339) + "\n"
+	}
+	rv = rv + "\n    git summary>\n"
+	for _, git := range b.Manifest.getGits() {
+		rv = rv + "        " + strings.Replace(git.String(), "\n", "\n        ", -1) + "\n"
+	}
+	return rv
+}
+
+// Returns a description of each difference between the binary and the
+// manifest.  A manifest made by 'release --commit-first' names the package
+// by its tag and has no hash so only the package's version is compared.
+func (b *BinaryInfo) Compare(manifest *PackageInfo) []string {
+	rv := []string{}
+	if b == nil || manifest == nil || manifest.Git == nil {
+		return rv
+	}
+	hash := manifest.Git.Hash
+	if hash != "" && b.Revision != "" && b.Revision != hash {
+		rv = append(rv, fmt.Sprintf("vcs.revision %v does not match manifest hash %v", b.Revision, hash))
+	}
+	if manifest.GoVersion != "" && b.GoVersion != "" && !strings.Contains(manifest.GoVersion+" ", " "+b.GoVersion+" ") {
+		rv = append(rv, fmt.Sprintf("built with %v but manifest made with %v", b.GoVersion, manifest.GoVersion))
+	}
+	pkgs := []string{}
+	ldflags := b.ldflagsPackages()
+	for pkg := range ldflags {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		vars := ldflags[pkg]
+		if hash != "" && vars["Hash"] != hash {
+			rv = append(rv, fmt.Sprintf("%v.Hash %v does not match manifest hash %v", pkg, vars["Hash"], hash))
+		}
+		if version, ok := vars["Version"]; ok && version != manifest.Git.Describe {
+			rv = append(rv, fmt.Sprintf("%v.Version %v does not match manifest version %v", pkg, version, manifest.Git.Describe))
+		}
+	}
+	// Module dependencies that are also gits in the manifest.
+	gits := make(map[string]*Git)
+	for _, git := range manifest.getGits() {
+		gits[git.HomeDir] = git
+	}
+	for _, dep := range b.Deps {
+		if git, ok := gits[dep.Path]; ok && !moduleMatchesGit(dep.Version, git) {
+			rv = append(rv, fmt.Sprintf("module %v %v does not match manifest %v (%v)", dep.Path, dep.Version, git.Describe, git.Hash))
+		}
+	}
+	if b.Version != nil {
+		if b.Version.Version != manifest.Git.Describe || b.Version.Hash != hash {
+			rv = append(rv, fmt.Sprintf("generated version %v (%v) does not match manifest %v (%v)", b.Version.Version, b.Version.Hash, manifest.Git.Describe, hash))
+		}
+		generated := []*Git{}
+		for _, dep := range b.Version.Dependencies {
+			generated = append(generated, &Git{HomeDir: dep.Name, Describe: dep.Version, Hash: dep.Hash})
+		}
+		rv = append(rv, compareGits("generated version information", generated, manifest)...)
+	}
+	if b.Manifest != nil && b.Manifest.Git != nil {
+		if b.Manifest.Git.Hash != hash {
+			rv = append(rv, fmt.Sprintf("embedded manifest hash %v does not match manifest hash %v", b.Manifest.Git.Hash, hash))
+		}
+		rv = append(rv, compareGits("embedded manifest", b.Manifest.getGits(), manifest)...)
+	}
+	return rv
+}
+
+// Returns the differences between the dependency gits found in the binary
+// at where, e.g. "embedded manifest", and those of the manifest.
+func compareGits(where string, found []*Git, manifest *PackageInfo) []string {
+	rv := []string{}
+	gits := make(map[string]*Git)
+	for _, git := range manifest.getGits() {
+		gits[git.HomeDir] = git
+	}
+	others := make(map[string]*Git)
+	for _, git := range found {
+		others[git.HomeDir] = git
+		if _, ok := gits[git.HomeDir]; !ok {
+			rv = append(rv, fmt.Sprintf("%v is not in the manifest", git.HomeDir))
+		}
+	}
+	for _, git := range manifest.getGits() {
+		if git.HomeDir == manifest.Git.HomeDir {
+			continue
+		}
+		if other, ok := others[git.HomeDir]; !ok {
+			rv = append(rv, fmt.Sprintf("%v is not in the %v", git.HomeDir, where))
+		} else if other.Hash != git.Hash {
+			rv = append(rv, fmt.Sprintf("%v is at %v (%v) in the binary but %v (%v) in the manifest", git.HomeDir, other.Describe, other.Hash, git.Describe, git.Hash))
+		}
+	}
+	return rv
+}
+
+// Returns true if the module version, a tag or pseudo-version, refers to
+// the commit of git.
+func moduleMatchesGit(version string, git *Git) bool {
+	// Pseudo-versions end in the first 12 characters of the hash.
+	if k := strings.LastIndex(version, "-"); k >= 0 && len(version)-k-1 == 12 && strings.HasPrefix(git.Hash, version[k+1:]) {
+		return true
+	}
+	describe := git.DescribeParts()
+	if describe.Tag == "" || describe.Commits != 0 {
+		return false
+	}
+	return strings.TrimPrefix(strings.TrimSuffix(version, "+incompatible"), "v") == strings.TrimPrefix(describe.Tag, "v")
+}
+
+// Prints the version information in binary and, if compare is true,
+// reports where it differs from the manifest File.
+func (g *GoGetVers) Inspect(binary string, compare bool) error {
+	if g == nil {
+		return errors.New("nil receiver")
+	}
+	info, err := InspectBinary(binary)
+	if err != nil {
+		g.Status.Error(err)
+		return err
+	}
+	g.Status.Writeln(info.String())
+	if !compare {
+		return nil
+	}
+	g.PackageInfo, err = LoadPackageInfoFile(g.File)
+	if err != nil {
+		g.Status.Error(err)
+		return err
+	}
+	g.Status.Printf("Comparing with manifest @ %v\n", g.File)
+	mismatches := info.Compare(g.PackageInfo)
+	if len(mismatches) == 0 {
+		g.Status.Writeln("Binary matches manifest.")
+		return nil
+	}
+	g.Status.Indent()
+	for _, mismatch := range mismatches {
+		g.Status.Writeln("MISMATCH " + mismatch)
+	}
+	g.Status.Outdent()
+	err = errors.New(fmt.Sprintf("%v mismatches between %v and %v", len(mismatches), binary, g.File))
+	g.Status.Error(err)
+	return err
+}
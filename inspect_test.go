@@ -0,0 +1,48 @@
+package gogetvers
+
+import (
+	"testing"
+)
+
+func TestInspectGeneratedWithoutEmbed(t *testing.T) {
+	info := NewPackageInfo("example.com/proj", "")
+	info.Git = &Git{HomeDir: "example.com/proj", Hash: fakeHash, Describe: "1.2.0-3-g0123abcd"}
+	info.DepsGit = []*GitDependency{{Git: &Git{HomeDir: "example.com/dep", Hash: fakeDepHash, Describe: "0.4.0-0-g4567ef01"}}}
+	main := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(VersionInfo.Version)\n}\n"
+	files := map[string]*TemplateData{"generated_gogetvers.go": generatedData(t, info, GenerateOptions{})}
+	bi, err := InspectBinary(buildGenerated(t, files, main))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bi.Version == nil || bi.Version.Hash != fakeHash || bi.Version.Version != "1.2.0-3-g0123abcd" {
+		t.Fatalf("version information %+v", bi.Version)
+	}
+	if bi.Manifest != nil {
+		t.Error("manifest found without --embed")
+	}
+	if diffs := bi.Compare(info); len(diffs) != 0 {
+		t.Errorf("binary differs from its own manifest: %v", diffs)
+	}
+	info.DepsGit[0].Git.Hash = fakeHash
+	if diffs := bi.Compare(info); len(diffs) != 1 {
+		t.Errorf("changed dependency hash gives %v", diffs)
+	}
+}
+
+func TestCompareCommitFirstManifest(t *testing.T) {
+	// A manifest made by 'release --commit-first' has the tag and no hash.
+	manifest := NewPackageInfo("example.com/proj", "")
+	manifest.Git = &Git{HomeDir: "example.com/proj", Describe: "0.2.1"}
+	bi := &BinaryInfo{
+		Revision: fakeHash,
+		Ldflags:  map[string]string{"gogetvers/version.Hash": fakeHash, "gogetvers/version.Version": "0.2.1", "gogetvers/version.Dependencies": ""},
+		Version:  &VersionData{Version: "0.2.1"},
+	}
+	if diffs := bi.Compare(manifest); len(diffs) != 0 {
+		t.Errorf("commit-first manifest gives %v", diffs)
+	}
+	bi.Version.Version = "0.2.0"
+	if diffs := bi.Compare(manifest); len(diffs) != 1 {
+		t.Errorf("wrong version gives %v", diffs)
+	}
+}
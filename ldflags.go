@@ -0,0 +1,81 @@
+package gogetvers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The characters go build splits -ldflags on.
+const ldflagsSpace = " \t\n\r"
+
+// Import path of the package that the values from Ldflags() populate.
+const LdflagsPackage = "gogetvers/version"
+
+// Returns a string for 'go build -ldflags' that sets the variables of
+// the package at importPath (LdflagsPackage if empty) from the manifest.
+func (g *GoGetVers) Ldflags(importPath string) (string, error) {
+	if g == nil {
+		return "", errors.New("nil receiver")
+	}
+	g.Status.Printf("Creating ldflags from manifest @ %v\n", g.File)
+	//
+	var err error
+	g.PackageInfo, err = LoadPackageInfoFile(g.File)
+	if err != nil {
+		g.Status.Error(err)
+		return "", err
+	}
+	g.Status.Writeln("Load manifest successful.")
+	//
+	if importPath == "" {
+		importPath = LdflagsPackage
+	}
+	data := newTemplateData(g.PackageInfo, "")
+	deps := []string{}
+	for _, git := range data.Gits {
+		deps = append(deps, git.HomeDir+"="+git.Describe)
+	}
+	manifestTime := ""
+	if !g.PackageInfo.Created.IsZero() {
+		manifestTime = g.PackageInfo.Created.Format(time.RFC3339)
+	}
+	vars := []struct {
+		name  string
+		value string
+	}{
+		{"Version", g.PackageInfo.Git.Describe},
+		{"Hash", g.PackageInfo.Git.Hash},
+		{"Branch", g.PackageInfo.Git.Branch},
+		{"OriginUrl", g.PackageInfo.Git.OriginUrl},
+		{"Dirty", fmt.Sprintf("%v", data.Dirty)},
+		{"ManifestTime", manifestTime},
+		{"Dependencies", strings.Join(deps, ",")}}
+	rv := []string{}
+	for _, v := range vars {
+		flag, err := ldflagsX(fmt.Sprintf("%v.%v=%v", importPath, v.name, v.value))
+		if err != nil {
+			g.Status.Error(err)
+			return "", err
+		}
+		rv = append(rv, flag)
+	}
+	return strings.Join(rv, " "), nil
+}
+
+// Returns "-X setting" quoted for go build, which splits -ldflags on
+// white space unless a field starts with ' or "; the field then ends at
+// the matching quote and there are no escapes.  A setting with white
+// space and both quotes can't be given and is an error.
+func ldflagsX(setting string) (string, error) {
+	switch {
+	case !strings.ContainsAny(setting, ldflagsSpace):
+		return "-X " + setting, nil
+	case !strings.Contains(setting, "'"):
+		return "-X '" + setting + "'", nil
+	case !strings.Contains(setting, "\""):
+		return "-X \"" + setting + "\"", nil
+	}
+	return "", errors.New(fmt.Sprintf("can not quote for -ldflags; has white space and both quotes: %v", setting))
+}
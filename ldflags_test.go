@@ -0,0 +1,51 @@
+package gogetvers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLdflagsX(t *testing.T) {
+	tests := []struct {
+		setting string
+		want    string
+		fails   bool
+	}{
+		{"p.Hash=0123abcd", "-X p.Hash=0123abcd", false},
+		{"p.Version=it's", "-X p.Version=it's", false},
+		{`p.Version=say"hi"`, `-X p.Version=say"hi"`, false},
+		{"p.Branch=my branch", "-X 'p.Branch=my branch'", false},
+		{"p.Branch=it's mine", `-X "p.Branch=it's mine"`, false},
+		{`p.Branch=say "hi"`, `-X 'p.Branch=say "hi"'`, false},
+		{`p.Branch=it's "mine"`, "", true},
+	}
+	for _, test := range tests {
+		got, err := ldflagsX(test.setting)
+		if (err != nil) != test.fails || got != test.want {
+			t.Errorf("ldflagsX(%q) = %q, %v; want %q", test.setting, got, err, test.want)
+			continue
+		}
+		// go build, and inspect, must get the setting back.
+		if fields := SplitQuoted(got); !test.fails && !reflect.DeepEqual(fields, []string{"-X", test.setting}) {
+			t.Errorf("SplitQuoted(%q) = %q", got, fields)
+		}
+	}
+}
+
+func TestSplitQuoted(t *testing.T) {
+	tests := []struct {
+		str  string
+		want []string
+	}{
+		{"", []string{}},
+		{"  -s  -w ", []string{"-s", "-w"}},
+		{`-X 'a.B=c d' -X "e.F=g h"`, []string{"-X", "a.B=c d", "-X", "e.F=g h"}},
+		{`-X a.B=it's`, []string{"-X", "a.B=it's"}},
+		{`-X 'a.B=open`, []string{"-X", "a.B=open"}},
+	}
+	for _, test := range tests {
+		if got := SplitQuoted(test.str); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("SplitQuoted(%q) = %q; want %q", test.str, got, test.want)
+		}
+	}
+}
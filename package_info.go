@@ -1,20 +1,25 @@
 package gogetvers
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 )
 
 // PackageInfo summarizes a package and its dependencies.
 type PackageInfo struct {
-	PackageDir string // Package source directory; absolute path.
-	RootDir    string // The root directory that contains everything.
-	Git        *Git   // Git info for package.
+	PackageDir string    // Package source directory; absolute path.
+	RootDir    string    // The root directory that contains everything.
+	Git        *Git      // Git info for package.
+	GoVersion  string    // Output of 'go version' for the toolchain that made the manifest.
+	Created    time.Time // When the manifest was made.
 	// Dependencies
 	DepsBuiltin   []*BuiltinDependency
 	DepsGit       []*GitDependency
@@ -47,6 +52,19 @@ func (p *PackageInfo) SetPathsComposite() {
 	}
 }
 
+// Sets the Runner used by the package git and all dependency gits.
+func (p *PackageInfo) SetRunner(runner Runner) {
+	if p == nil {
+		return
+	}
+	if p.Git != nil {
+		p.Git.Runner = runner
+	}
+	for _, dep := range p.DepsGit {
+		dep.Git.Runner = runner
+	}
+}
+
 // Opens the input file and decodes the manifest.
 func LoadPackageInfoFile(inputFile string) (*PackageInfo, error) {
 	if !IsFile(inputFile) {
@@ -64,15 +82,45 @@ func LoadPackageInfoFile(inputFile string) (*PackageInfo, error) {
 	if err != nil {
 		return nil, err
 	}
+	if summary.Git == nil {
+		return nil, errors.New(fmt.Sprintf("manifest has no git for the package @ %v", inputFile))
+	}
 	// We have to reset paths composites as they aren't set in the JSON file.
 	summary.SetPathsComposite()
 	//
 	return summary, nil
 }
 
+// Returns the manifest as it is written to a file.
+func (p *PackageInfo) encode() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := json.NewEncoder(buf).Encode(p)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Returns true if p and other are the same manifest apart from Created.
+func (p *PackageInfo) sameContent(other *PackageInfo) bool {
+	if p == nil || other == nil {
+		return false
+	}
+	created := p.Created
+	p.Created = other.Created
+	mine, err := p.encode()
+	p.Created = created
+	if err != nil {
+		return false
+	}
+	theirs, err := other.encode()
+	return err == nil && bytes.Equal(mine, theirs)
+}
+
 // Create a new package info type by analyzing a directory continaining the
-// package.
-func getPackageInfo(packageDir string, status *StatusWriter) (*PackageInfo, error) {
+// package; all commands are run with runner.
+func getPackageInfo(packageDir string, runner Runner, status *StatusWriter) (*PackageInfo, error) {
+	runner = getRunner(runner)
 	// Absolute path.
 	packageDir, err := filepath.Abs(packageDir)
 	if err != nil {
@@ -83,7 +131,7 @@ func getPackageInfo(packageDir string, status *StatusWriter) (*PackageInfo, erro
 	status.Printf("Get package info for package @ %v\n", packageDir)
 	// Get 'go list' information; this is package information according to golang.
 	golist := NewCommandGoList()
-	err = golist.Exec(packageDir)
+	err = runner.Run(golist, packageDir)
 	if err != nil {
 		status.Error(err)
 		return nil, err
@@ -100,7 +148,7 @@ func getPackageInfo(packageDir string, status *StatusWriter) (*PackageInfo, erro
 	rootDir = strings.TrimRight(rootDir, "\\/")
 	status.Printf("Root path @ %v\n", rootDir)
 	// Get the git info for package.
-	git, err := NewGitByFind(packageDir, rootDir)
+	git, err := NewGitByFindWithRunner(packageDir, rootDir, runner)
 	if err != nil {
 		status.Error(err)
 		return nil, err
@@ -108,22 +156,32 @@ func getPackageInfo(packageDir string, status *StatusWriter) (*PackageInfo, erro
 	status.Writeln("Found package git information")
 	// Get dependency information.
 	golistdeps := NewCommandGoListDeps()
-	err = golistdeps.Exec(packageDir)
+	err = runner.Run(golistdeps, packageDir)
 	if err != nil {
 		status.Error(err)
 		return nil, err
 	}
 	status.Printf("Dependencies are: %v\n", strings.Replace(golistdeps.Output, " ", ", ", -1))
+	// Record the toolchain.
+	goversion := NewCommandGoVersion()
+	err = runner.Run(goversion, packageDir)
+	if err != nil {
+		status.Error(err)
+		return nil, err
+	}
+	status.Printf("Toolchain is %v\n", goversion.Output)
 	// Our return value.
 	rv := NewPackageInfo(packageDir, rootDir)
 	rv.Git = git
+	rv.GoVersion = goversion.Output
+	rv.Created = time.Now().UTC()
 	// Get information for each dependency.
 	status.Writeln("Getting dependency information...")
 	status.Indent()
 	deps := strings.Split(golistdeps.Output, " ")
 	for _, depName := range deps {
 		status.Printf("%v...", depName)
-		dep, err := GetDependency(filepath.Join(rv.RootDir, depName), rv.RootDir)
+		dep, err := GetDependencyWithRunner(filepath.Join(rv.RootDir, depName), rv.RootDir, runner)
 		if err != nil {
 			status.Error(err)
 			return nil, err
@@ -145,11 +203,65 @@ func getPackageInfo(packageDir string, status *StatusWriter) (*PackageInfo, erro
 	return rv, nil
 }
 
+// Describes the package's git by tag alone, for a manifest that is
+// committed before the tag is made: Describe is tag and Hash is empty
+// since a commit can't contain its own hash.  See Git.DescribeParts().
+func (p *PackageInfo) setReleaseTag(tag string) {
+	if p == nil || p.Git == nil {
+		return
+	}
+	p.Git.Describe, p.Git.Hash = tag, ""
+	// The package's git may also be listed as a dependency.
+	for _, dep := range p.DepsGit {
+		if dep.Git != nil && dep.Git.HomeDir == p.Git.HomeDir {
+			dep.Git.Describe, dep.Git.Hash = tag, ""
+		}
+	}
+}
+
+// Returns the gits of the dependencies that are not at a tagged commit,
+// as "name (describe)" where name is relative to RootDir, except those
+// matched by a name or path.Match pattern in allow.
+func (p *PackageInfo) getUntaggedDeps(allow []string) []string {
+	rv := []string{}
+	for _, git := range p.getGits() {
+		if p.Git != nil && git.HomeDir == p.Git.HomeDir {
+			continue
+		}
+		describe := git.DescribeParts()
+		if describe.Tag != "" && describe.Commits == 0 {
+			continue
+		}
+		// Names are relative to RootDir unless the paths were stripped.
+		name := filepath.ToSlash(git.HomeDir)
+		if p.RootDir != "" {
+			name = strings.TrimPrefix(name, strings.TrimRight(filepath.ToSlash(p.RootDir), "/")+"/")
+		}
+		allowed := false
+		for _, pattern := range allow {
+			if matched, _ := path.Match(pattern, name); matched || pattern == name {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			rv = append(rv, fmt.Sprintf("%v (%v)", name, git.Describe))
+		}
+	}
+	return rv
+}
+
 // Return a package summary.
 func (p *PackageInfo) getSummary() string {
 	rv := "Package Summary\n"
 	rv = rv + "    home> " + p.PackageDir + "\n"
 	rv = rv + "    root> " + p.RootDir + "\n"
+	if !p.Created.IsZero() {
+		rv = rv + "    created> " + p.Created.Format(time.RFC3339) + "\n"
+	}
+	if p.GoVersion != "" {
+		rv = rv + "    toolchain> " + p.GoVersion + "\n"
+	}
 	rv = rv + "    gits>\n"
 	if len(p.DepsGit) > 0 {
 		rv = rv + "        " + strings.Join(p.getGitNames(), ", ") + "\n"
@@ -186,7 +298,7 @@ func (p *PackageInfo) getGits() GitList {
 		}
 	}
 	// Package git
-	if _, ok := found[p.Git.HomeDir]; !ok {
+	if p.Git != nil && !found[p.Git.HomeDir] {
 		found[p.Git.HomeDir] = true
 		gits = append(gits, p.Git)
 	}
@@ -204,7 +316,7 @@ func (p *PackageInfo) getGitsDiskStatus() (exist GitList, dne GitList) {
 	}
 	//
 	for _, v := range p.getGits() {
-		if IsDir(v.HomeDir) {
+		if runnerIsDir(v.Runner, v.HomeDir) {
 			yeslist = append(yeslist, v)
 		} else {
 			nolist = append(nolist, v)
@@ -228,7 +340,7 @@ func (p *PackageInfo) getGitsLocalModsStatus() (mods GitList, nomods GitList, dn
 	//
 	exist, dne := p.getGitsDiskStatus()
 	for _, git := range exist {
-		newgit, err := NewGit(git.HomeDir)
+		newgit, err := NewGitWithRunner(git.HomeDir, git.Runner)
 		if err != nil {
 			return nil, nil, nil, err
 		}
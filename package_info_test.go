@@ -0,0 +1,94 @@
+package gogetvers
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestMakeKeepsCreatedWhenUnchanged(t *testing.T) {
+	g, fake := newFakeGoGetVers(t)
+	if err := g.Make(); err != nil {
+		t.Fatal(err)
+	}
+	first, err := ioutil.ReadFile(g.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = g.Make(); err != nil {
+		t.Fatal(err)
+	}
+	second, err := ioutil.ReadFile(g.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first) != string(second) {
+		t.Errorf("unchanged manifest was rewritten:\n%s\n%s", first, second)
+	}
+	// A moved dependency is a new manifest.
+	fake.Script(fakeDep, "git rev-parse HEAD", "89abcdef89abcdef89abcdef89abcdef89abcdef", 0)
+	old, _ := LoadPackageInfoFile(g.File)
+	if err = g.Make(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := LoadPackageInfoFile(g.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Created.After(old.Created) {
+		t.Errorf("Created %v not after %v", info.Created, old.Created)
+	}
+}
+
+func TestGetUntaggedDeps(t *testing.T) {
+	info := NewPackageInfo("src/example.com/proj", "/go")
+	info.Git = &Git{HomeDir: "/go/src/example.com/proj", Describe: "1.0.0-2-g0123abcd"}
+	deps := map[string]string{
+		"/go/src/example.com/tagged":      "0.4.0-0-g4567ef01",
+		"/go/src/example.com/ahead":       "0.4.0-3-g4567ef01",
+		"/go/src/example.com/none":        "4567ef01",
+		"/go/src/github.com/user/lib":     "4567ef01",
+		"/go/src/github.com/user/lib/sub": "4567ef01",
+	}
+	for dir, describe := range deps {
+		info.DepsGit = append(info.DepsGit, &GitDependency{Name: dir, Git: &Git{HomeDir: dir, Hash: fakeDepHash, Describe: describe}})
+	}
+	tests := []struct {
+		allow []string
+		want  string
+	}{
+		{nil, "src/example.com/ahead (0.4.0-3-g4567ef01), src/example.com/none (4567ef01), src/github.com/user/lib (4567ef01), src/github.com/user/lib/sub (4567ef01)"},
+		{[]string{"src/github.com/user/*"}, "src/example.com/ahead (0.4.0-3-g4567ef01), src/example.com/none (4567ef01), src/github.com/user/lib/sub (4567ef01)"},
+		{[]string{"src/example.com/none", "src/github.com/*/*", "src/github.com/*/*/*"}, "src/example.com/ahead (0.4.0-3-g4567ef01)"},
+	}
+	for _, test := range tests {
+		untagged := info.getUntaggedDeps(test.allow)
+		sort.Strings(untagged)
+		got := strings.Join(untagged, ", ")
+		if got != test.want {
+			t.Errorf("untagged with %v is %v; want %v", test.allow, got, test.want)
+		}
+	}
+}
+
+func TestReleaseRefusesUntaggedDeps(t *testing.T) {
+	g, fake := newFakeGoGetVers(t)
+	fake.Script(fakeDep, "git describe --tags --abbrev=8 --always --long", "0.4.0-1-g4567ef01", 0)
+	g.ReleaseOptions.RequireTagged = true
+	err := g.Release(filepath.Join(t.TempDir(), "generated_gogetvers.go"), "proj", "1.3.0", "")
+	if err == nil || !strings.Contains(err.Error(), "example.com/dep (0.4.0-1-g4567ef01)") {
+		t.Fatalf("release with an untagged dependency returned %v", err)
+	}
+	for _, call := range fake.Commands() {
+		if strings.HasPrefix(call, "git tag") {
+			t.Errorf("release ran %v", call)
+		}
+	}
+	g.ReleaseOptions.AllowUntagged = []string{"example.com/*"}
+	fake.Default = &FakeResult{}
+	if err = g.Release(filepath.Join(t.TempDir(), "generated_gogetvers.go"), "proj", "1.3.0", ""); err != nil {
+		t.Errorf("release with an allowed dependency returned %v", err)
+	}
+}
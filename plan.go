@@ -0,0 +1,105 @@
+package gogetvers
+
+import (
+	"strings"
+)
+
+// A single step recorded in a Plan.
+type PlanStep struct {
+	Action string // One of "run", "write" or "mkdir".
+	Dir    string // Working directory of a "run" step.
+	Target string // Command for "run" steps; file or directory path otherwise.
+}
+
+// Plan records the mutating commands and file system changes that a
+// dry run would have performed.
+type Plan struct {
+	Steps []PlanStep
+}
+
+// Creates a new, empty Plan.
+func NewPlan() *Plan {
+	return &Plan{Steps: []PlanStep{}}
+}
+
+// Records that cmd would be run in dir.
+func (p *Plan) AddCommand(cmd *Command, dir string) {
+	if p == nil {
+		return
+	}
+	p.Steps = append(p.Steps, PlanStep{Action: "run", Dir: dir, Target: cmd.String()})
+}
+
+// Records that the file system would be changed at path; action is
+// "write" or "mkdir".
+func (p *Plan) AddFile(action, path string) {
+	if p == nil {
+		return
+	}
+	p.Steps = append(p.Steps, PlanStep{Action: action, Target: path})
+}
+
+// Returns true if the plan writes the file at path.
+func (p *Plan) Writes(path string) bool {
+	if p == nil {
+		return false
+	}
+	for _, step := range p.Steps {
+		if step.Action == "write" && step.Target == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the plan as a string for printing; one step per line.
+func (p *Plan) String() string {
+	if p == nil {
+		return ""
+	}
+	rv := []string{}
+	for _, step := range p.Steps {
+		switch step.Action {
+		case "run":
+			rv = append(rv, "cd "+step.Dir+" && "+step.Target)
+		case "mkdir":
+			rv = append(rv, "mkdir -p "+step.Target)
+		default:
+			rv = append(rv, step.Action+" "+step.Target)
+		}
+	}
+	if len(rv) == 0 {
+		return "nothing to do\n"
+	}
+	return strings.Join(rv, "\n") + "\n"
+}
+
+// A Runner that records mutating commands in a Plan instead of running
+// them; all other commands are passed to the wrapped Runner.
+type planRunner struct {
+	Runner Runner
+	Plan   *Plan
+}
+
+// Satisfies Runner interface.
+func (r *planRunner) Run(cmd *Command, chdir string) error {
+	if cmd != nil && cmd.Mutates {
+		r.Plan.AddCommand(cmd, chdir)
+		cmd.Output = ""
+		cmd.ExitCode = 0
+		return nil
+	}
+	return getRunner(r.Runner).Run(cmd, chdir)
+}
+
+// Returns the Plan that runner records into or nil if runner
+// performs its work.
+func getPlan(runner Runner) *Plan {
+	switch r := runner.(type) {
+	case *planRunner:
+		return r.Plan
+	case *toolchainRunner:
+		return getPlan(r.Runner)
+	}
+	return nil
+}
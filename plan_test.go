@@ -0,0 +1,32 @@
+package gogetvers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDryRunPlansFetchAndVerify(t *testing.T) {
+	g, fake := newFakeGoGetVers(t)
+	g.Plan = NewPlan()
+	if err := g.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	fake.Script(fakePackage, "git config --get branch.master.remote", "origin", 0)
+	fake.Script(fakePackage, "git config --get branch.master.merge", "refs/heads/master", 0)
+	fake.Script(fakePackage, "git rev-parse --abbrev-ref @{upstream}", "origin/master", 0)
+	fake.Script(fakePackage, "git rev-list --count HEAD..origin/master", "0", 0)
+	if err := g.checkUpstream("origin", "master"); err != nil {
+		t.Fatal(err)
+	}
+	for _, call := range fake.Commands() {
+		if strings.HasPrefix(call, "go ") || strings.HasPrefix(call, "git fetch") {
+			t.Errorf("dry run ran %v", call)
+		}
+	}
+	plan := g.Plan.String()
+	for _, want := range []string{"go build -o", "go vet .", "go test .", "git fetch origin"} {
+		if !strings.Contains(plan, want) {
+			t.Errorf("plan does not have %v:\n%v", want, plan)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+package gogetvers
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Returns the tag for the next release: the latest tag of the package's
+// git, from 'git describe', with part (one of the Bump constants)
+// incremented.  The tag keeps the latest tag's 'v' prefix; if there are
+// no tags then the version bumped is 0.0.0.  It is an error if the result
+// is not greater than every semantic version tag in the git.
+func (g *GoGetVers) NextTag(part string) (string, error) {
+	if g == nil {
+		return "", errors.New("nil receiver")
+	}
+	gitdescribe := NewCommandGitDescribe()
+	err := g.runner().Run(gitdescribe, g.Path)
+	if err != nil {
+		g.Status.Error(err)
+		return "", err
+	}
+	describe, err := ParseDescribe(gitdescribe.Output)
+	if err != nil {
+		g.Status.Error(err)
+		return "", err
+	}
+	latest, prefix, from := &SemVer{}, "", "0.0.0"
+	if describe.Tag != "" {
+		if describe.SemVer == nil {
+			err = errors.New(fmt.Sprintf("latest tag is not a semantic version: %v", describe.Tag))
+			g.Status.Error(err)
+			return "", err
+		}
+		latest, from = describe.SemVer, describe.Tag
+		if strings.HasPrefix(describe.Tag, "v") {
+			prefix = "v"
+		}
+	}
+	next, err := latest.Bump(part)
+	if err != nil {
+		g.Status.Error(err)
+		return "", err
+	}
+	// The latest tag from describe is only the nearest; check every tag.
+	gittags := NewCommandGitTagList()
+	err = g.runner().Run(gittags, g.Path)
+	if err != nil {
+		g.Status.Error(err)
+		return "", err
+	}
+	for _, tag := range strings.Split(gittags.Output, "\n") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		version, err := ParseSemVer(tag)
+		if err != nil {
+			continue
+		}
+		if next.Compare(version) <= 0 {
+			err = errors.New(fmt.Sprintf("next version %v%v is not greater than existing tag %v", prefix, next, tag))
+			g.Status.Error(err)
+			return "", err
+		}
+	}
+	rv := prefix + next.String()
+	g.Status.Printf("Bumped %v of %v to %v\n", part, from, rv)
+	return rv, nil
+}
+
+// The remote Release pushes to if ReleaseOptions.Remote is empty.
+const DefaultRemote = "origin"
+
+// A completed step of Release and the command that undoes it.
+type releaseStep struct {
+	description string   // What was done, e.g. "created tag 1.2.0".
+	undo        *Command // Undoes the step.
+}
+
+// Simplifies creating and tagging a production release of a package.
+// Release is transactional: if any step fails then the steps already
+// completed are undone in reverse order; the tag is deleted locally and
+// from the remote and the git is reset to the commit it started at.  The
+// current branch is pushed last, if requested, so it is never undone.
+func (g *GoGetVers) Release(gofile, packageName, tag, message string) error {
+	if g == nil {
+		return errors.New("nil receiver")
+	}
+	env := []string{HookEnvGoFile + "=" + gofile, HookEnvPackage + "=" + packageName, HookEnvTag + "=" + tag, HookEnvMessage + "=" + message}
+	err := g.runHooks("pre", OperationRelease, env)
+	if err != nil {
+		return err
+	}
+	err = g.release(gofile, packageName, tag, message)
+	if err != nil {
+		return err
+	}
+	// The release can't be undone now so make sure it isn't retried.
+	err = g.runHooks("post", OperationRelease, env)
+	if err != nil {
+		remote := g.ReleaseOptions.Remote
+		if remote == "" {
+			remote = DefaultRemote
+		}
+		return errors.New(fmt.Sprintf("release of %v succeeded and it was pushed to %v but %v; do not release it again", tag, remote, err))
+	}
+	return nil
+}
+
+// Does the work of Release without running hooks.
+func (g *GoGetVers) release(gofile, packageName, tag, message string) (rverr error) {
+	var err error
+	//
+	if tag == "" {
+		return errors.New("tag is empty")
+	}
+	// Get package information because we need to check for local modifications.
+	g.PackageInfo, err = getPackageInfo(g.Path, g.runner(), nil)
+	if err != nil {
+		g.Status.Error(err)
+		return err
+	}
+	//
+	gits := g.PackageInfo.getGits()
+	gitsWMods := []string{}
+	for _, git := range gits {
+		if git.Status != "" {
+			gitsWMods = append(gitsWMods, git.HomeDir)
+		}
+	}
+	if len(gitsWMods) > 0 {
+		err := errors.New("The following repositories have local modifications: " + strings.Join(gitsWMods, ", "))
+		g.Status.Error(err)
+		return err
+
+	}
+	if g.ReleaseOptions.RequireTagged {
+		untagged := g.PackageInfo.getUntaggedDeps(g.ReleaseOptions.AllowUntagged)
+		if len(untagged) > 0 {
+			err := errors.New("The following dependencies are not at a tagged commit: " + strings.Join(untagged, ", "))
+			g.Status.Error(err)
+			return err
+		}
+	}
+	remote := g.ReleaseOptions.Remote
+	if remote == "" {
+		remote = DefaultRemote
+	}
+	branch, ref := "", ""
+	if g.ReleaseOptions.PushBranch || g.ReleaseOptions.CheckUpstream {
+		gitbranch := NewCommandGitCurrentBranch()
+		err = g.runner().Run(gitbranch, g.Path)
+		if err == nil && gitbranch.Output == "HEAD" {
+			err = errors.New("no branch is checked out")
+		}
+		if err != nil {
+			g.Status.Error(err)
+			return err
+		}
+		branch = gitbranch.Output
+		// The branch is pushed to its upstream, which must be on remote.
+		var upstream string
+		upstream, ref = g.getUpstream(branch)
+		if ref == "" {
+			ref = "refs/heads/" + branch
+		} else if upstream != remote {
+			err = errors.New(fmt.Sprintf("the upstream of %v is on %v, not %v", branch, upstream, remote))
+			g.Status.Error(err)
+			return err
+		}
+	}
+	if g.ReleaseOptions.CheckUpstream {
+		err = g.checkUpstream(remote, branch)
+		if err != nil {
+			g.Status.Error(err)
+			return err
+		}
+	}
+	if g.VerifyOptions.Release {
+		err = g.Verify()
+		if err != nil {
+			return err
+		}
+	}
+	original := g.PackageInfo.Git.Hash
+	// Undo what was done if a later step fails.
+	done := []releaseStep{}
+	defer func() {
+		if rverr != nil && len(done) > 0 {
+			g.rollback(done)
+		}
+	}()
+	//
+	if message == "" {
+		message = tag
+	}
+	if g.ReleaseOptions.CommitFirst {
+		err = g.releaseCommit(gofile, packageName, tag, message, original, &done)
+		if err == nil {
+			err = g.releaseTag(tag, message, remote, &done)
+		}
+	} else {
+		err = g.releaseTag(tag, message, remote, &done)
+		if err == nil {
+			err = g.releaseCommit(gofile, packageName, "", message, original, &done)
+		}
+	}
+	if err != nil || !g.ReleaseOptions.PushBranch {
+		return err
+	}
+	//
+	gitbranchpush := NewCommandGitBranchPush(branch, ref, remote)
+	g.Status.Writeln(gitbranchpush.String())
+	err = g.runner().Run(gitbranchpush, g.Path)
+	if err != nil {
+		g.Status.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Returns the remote and merge ref, e.g. refs/heads/master, of the
+// upstream of branch; both are empty if it has none.
+func (g *GoGetVers) getUpstream(branch string) (string, string) {
+	gitremote := NewCommandGitConfigGet("branch." + branch + ".remote")
+	gitmerge := NewCommandGitConfigGet("branch." + branch + ".merge")
+	if g.runner().Run(gitremote, g.Path) != nil || g.runner().Run(gitmerge, g.Path) != nil {
+		return "", ""
+	}
+	return gitremote.Output, gitmerge.Output
+}
+
+// Fetches remote and returns an error if branch, the current branch, is
+// behind its upstream, has none or has one on another remote.
+func (g *GoGetVers) checkUpstream(remote, branch string) error {
+	upremote, ref := g.getUpstream(branch)
+	if ref == "" {
+		return errors.New(fmt.Sprintf("%v has no upstream", branch))
+	}
+	if upremote != remote {
+		return errors.New(fmt.Sprintf("the upstream of %v is on %v, not %v", branch, upremote, remote))
+	}
+	gitfetch := NewCommandGitFetch(remote)
+	g.Status.Writeln(gitfetch.String())
+	err := g.runner().Run(gitfetch, g.Path)
+	if err != nil {
+		return err
+	}
+	gitupstream := NewCommandGitUpstream()
+	err = g.runner().Run(gitupstream, g.Path)
+	if err != nil {
+		return errors.New(fmt.Sprintf("%v has no upstream: %v", branch, err))
+	}
+	upstream := gitupstream.Output
+	gitbehind := NewCommandGitRevListCount("HEAD.." + upstream)
+	err = g.runner().Run(gitbehind, g.Path)
+	if err != nil {
+		return err
+	}
+	if gitbehind.Output != "0" {
+		return errors.New(fmt.Sprintf("the current branch is %v commit(s) behind %v", gitbehind.Output, upstream))
+	}
+	g.Status.Printf("Up to date with %v\n", upstream)
+	return nil
+}
+
+// Creates the annotated tag and pushes it to remote for Release; the
+// completed steps are added to done.
+func (g *GoGetVers) releaseTag(tag, message, remote string, done *[]releaseStep) error {
+	gittag := NewCommandGitTagAnnotated(tag, message)
+	g.Status.Writeln(gittag.String())
+	err := g.runner().Run(gittag, g.Path)
+	if err != nil {
+		g.Status.Error(err)
+		return err
+	}
+	*done = append(*done, releaseStep{"created tag " + tag, NewCommandGitTagDelete(tag)})
+	//
+	gittagpush := NewCommandGitTagPush(tag, remote)
+	g.Status.Writeln(gittagpush.String())
+	err = g.runner().Run(gittagpush, g.Path)
+	if err != nil {
+		g.Status.Error(err)
+		return err
+	}
+	*done = append(*done, releaseStep{"pushed tag " + tag + " to " + remote, NewCommandGitTagPushDelete(tag, remote)})
+	return nil
+}
+
+// Makes the manifest and generated file and commits them for Release;
+// if tag is not empty then the manifest describes the commit as tagged
+// with it.  The completed steps are added to done.
+func (g *GoGetVers) releaseCommit(gofile, packageName, tag, message, original string, done *[]releaseStep) error {
+	// Make, Generate and the commit change the git; resetting to the
+	// original commit, which had no local modifications, undoes them all
+	// except for new files that were never committed.  Those are removed
+	// by cleaning them after the reset, so that step comes first.
+	// The description lists the changes as they are made.
+	created := []string{}
+	for _, file := range []string{g.File, gofile} {
+		if path, err := filepath.Abs(file); err == nil && !IsFile(path) {
+			created = append(created, path)
+		}
+	}
+	if len(created) > 0 {
+		*done = append(*done, releaseStep{"created " + strings.Join(created, ", "), NewCommandGitCleanFiles(created...)})
+	}
+	changes := []string{}
+	reset := len(*done)
+	*done = append(*done, releaseStep{"reset to " + original, NewCommandGitResetHard(original)})
+	err := g.makeManifest(tag)
+	if err != nil {
+		g.Status.Error(err)
+		return err
+	}
+	changes = append(changes, "wrote "+g.File)
+	(*done)[reset].description = strings.Join(changes, ", ")
+	//
+	err = g.generate(gofile, packageName)
+	if err != nil {
+		g.Status.Error(err)
+		return err
+	}
+	changes = append(changes, "wrote "+gofile)
+	(*done)[reset].description = strings.Join(changes, ", ")
+	//
+	gitadd := NewCommandGitAdd(".")
+	g.Status.Writeln(gitadd.String())
+	err = g.runner().Run(gitadd, g.Path)
+	if err != nil {
+		g.Status.Error(err)
+		return err
+	}
+	//
+	gitcommit := NewCommandGitCommit(message)
+	g.Status.Writeln(gitcommit.String())
+	err = g.runner().Run(gitcommit, g.Path)
+	if err != nil {
+		g.Status.Error(err)
+		return err
+	}
+	changes = append(changes, "committed "+message)
+	(*done)[reset].description = strings.Join(changes, ", ")
+	return nil
+}
+
+// Undoes the steps of a failed Release in reverse order and reports what
+// was rolled back; a step that can't be undone is reported and the
+// remaining steps are still undone.
+func (g *GoGetVers) rollback(steps []releaseStep) {
+	g.Status.Warning("Release failed; rolling back.")
+	g.Status.Indent()
+	for k := len(steps) - 1; k >= 0; k-- {
+		step := steps[k]
+		g.Status.Writeln(step.undo.String())
+		err := g.runner().Run(step.undo, g.Path)
+		if err != nil {
+			g.Status.Error(errors.New(fmt.Sprintf("could not undo: %v: %v", step.description, err)))
+			continue
+		}
+		g.Status.Writeln("Rolled back: " + step.description)
+	}
+	g.Status.Outdent()
+}
@@ -0,0 +1,297 @@
+package gogetvers
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Runs go commands with Fake and everything else with Command.Exec() so
+// tests can use real gits without a go workspace.
+type goFakeRunner struct {
+	Fake *FakeRunner
+}
+
+// Satisfies Runner interface.
+func (r *goFakeRunner) Run(cmd *Command, chdir string) error {
+	if cmd.Bin == "go" {
+		return r.Fake.Run(cmd, chdir)
+	}
+	return cmd.Exec(chdir)
+}
+
+// Runs git with args in dir and returns its output.
+func runGit(t *testing.T, dir string, args ...string) string {
+	cmd := NewCommand("git", args...)
+	if err := cmd.Exec(dir); err != nil {
+		t.Fatalf("%v: %v", err, cmd.ErrorOutput)
+	}
+	return cmd.Output
+}
+
+// Creates the git of the package example.com/proj in a temporary source
+// root with a bare origin and returns its directory.
+func newGitPackage(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tmp := t.TempDir()
+	origin := filepath.Join(tmp, "origin.git")
+	dir := filepath.Join(tmp, "src", "example.com", "proj")
+	if err := Mkdir(dir, 0770); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, tmp, "init", "-q", "--bare", origin)
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "config", "user.name", "Test")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "commit.gpgsign", "false")
+	runGit(t, dir, "config", "tag.gpgsign", "false")
+	runGit(t, dir, "remote", "add", "origin", origin)
+	if err := ioutil.WriteFile(filepath.Join(dir, "proj.go"), []byte("package proj\n"), 0664); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "first")
+	return dir
+}
+
+// Returns a GoGetVers for the package made by newGitPackage.
+func newGitGoGetVers(t *testing.T, dir string) *GoGetVers {
+	fake := NewFakeRunner()
+	fake.Script(dir, "go list", "example.com/proj", 0)
+	fake.Script(dir, "go list -f {{.Deps}}", "[fmt]", 0)
+	fake.Script(dir, "go version", "go version go1.22.1 linux/amd64", 0)
+	g, err := NewGoGetVers(dir, filepath.Join(dir, "gogetvers.manifest"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Runner = &goFakeRunner{Fake: fake}
+	return g
+}
+
+func TestReleaseCommitFirstManifestNamesTag(t *testing.T) {
+	dir := newGitPackage(t)
+	g := newGitGoGetVers(t, dir)
+	g.ReleaseOptions.CommitFirst = true
+	gofile := filepath.Join(dir, "generated_gogetvers.go")
+	if err := g.Release(gofile, "proj", "0.2.1", "release 0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	tagged := runGit(t, dir, "rev-parse", "0.2.1^{commit}")
+	if head := runGit(t, dir, "rev-parse", "HEAD"); head != tagged {
+		t.Fatalf("tag is on %v but release commit is %v", tagged, head)
+	}
+	// Move on and then back to the release with the manifest at the tag.
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "after")
+	runGit(t, dir, "checkout", "-q", "0.2.1")
+	manifest, err := LoadPackageInfoFile(g.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Git.Describe != "0.2.1" || (manifest.Git.Hash != "" && manifest.Git.Hash != tagged) {
+		t.Errorf("manifest has %v at %v but 0.2.1 is %v", manifest.Git.Describe, manifest.Git.Hash, tagged)
+	}
+	source, err := ioutil.ReadFile(gofile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`\bVersion: +"0\.2\.1",`).Match(source) {
+		t.Errorf("generated version is not the tag:\n%s", source)
+	}
+	// Checking out the manifest's git lands on the tag.
+	runGit(t, dir, "checkout", "-q", "-")
+	git := manifest.Git
+	git.HomeDir = dir
+	if err = git.Checkout(); err != nil {
+		t.Fatal(err)
+	}
+	if head := runGit(t, dir, "rev-parse", "HEAD"); head != tagged {
+		t.Errorf("checkout of manifest is %v; want %v", head, tagged)
+	}
+}
+
+func TestNextTag(t *testing.T) {
+	tests := []struct {
+		describe, tags, part string
+		want                 string // Empty if NextTag fails.
+	}{
+		{"v1.2.0-3-g0123abcd", "v1.1.0\nv1.2.0", BumpMinor, "v1.3.0"},
+		{"1.2.0-0-g0123abcd", "1.2.0", BumpPatch, "1.2.1"},
+		{"0123abcd", "", BumpMinor, "0.1.0"},
+		{"1.2.0-rc.1-2-g0123abcd", "1.2.0-rc.1", BumpPrerelease, "1.2.0-rc.2"},
+		// A greater tag on another branch.
+		{"1.2.0-3-g0123abcd", "1.2.0\n1.3.0\nrelease", BumpMinor, ""},
+		{"release-3-g0123abcd", "release", BumpMinor, ""},
+		{"1.2.0-3-g0123abcd", "1.2.0", "build", ""},
+	}
+	for _, test := range tests {
+		g, fake := newFakeGoGetVers(t)
+		fake.Script(fakePackage, "git describe --tags --abbrev=8 --always --long", test.describe, 0)
+		fake.Script(fakePackage, "git tag --list", test.tags, 0)
+		got, err := g.NextTag(test.part)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("%v bump of %v = %v; want an error", test.part, test.describe, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("%v bump of %v = %v, %v; want %v", test.part, test.describe, got, err, test.want)
+		}
+	}
+}
+
+func TestReleaseRollbackOrder(t *testing.T) {
+	g, fake := newFakeGoGetVers(t)
+	fake.Default = &FakeResult{}
+	fake.Script(fakePackage, "git commit -m release", "", 1)
+	gofile := filepath.Join(t.TempDir(), "generated_gogetvers.go")
+	if err := g.Release(gofile, "proj", "1.3.0", "release"); err == nil {
+		t.Fatal("release succeeded with a failing commit")
+	}
+	calls := fake.Commands()
+	k := 0
+	for k < len(calls) && calls[k] != "git commit -m release" {
+		k++
+	}
+	want := []string{
+		"git reset --hard " + fakeHash,
+		"git clean -f -x -- " + g.File + " " + gofile,
+		"git push origin :refs/tags/1.3.0",
+		"git tag -d 1.3.0",
+	}
+	if k == len(calls) || strings.Join(calls[k+1:], "\n") != strings.Join(want, "\n") {
+		t.Errorf("rolled back with\n%v\nwant\n%v", strings.Join(calls[k+1:], "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestReleaseRollbackRemovesNewFiles(t *testing.T) {
+	dir := newGitPackage(t)
+	g := newGitGoGetVers(t, dir)
+	// Generate fails after the manifest is written but before it is added.
+	g.GenerateOptions.BuildConstraint = "linux &&"
+	head := runGit(t, dir, "rev-parse", "HEAD")
+	gofile := filepath.Join(dir, "generated_gogetvers.go")
+	if err := g.Release(gofile, "proj", "0.2.1", "release 0.2.1"); err == nil {
+		t.Fatal("release succeeded with a failing generate")
+	}
+	for _, file := range []string{g.File, gofile} {
+		if IsFile(file) {
+			t.Errorf("%v was not removed", file)
+		}
+	}
+	if status := runGit(t, dir, "status", "--porcelain"); status != "" {
+		t.Errorf("git is not clean:\n%v", status)
+	}
+	if after := runGit(t, dir, "rev-parse", "HEAD"); after != head {
+		t.Errorf("HEAD moved from %v to %v", head, after)
+	}
+	if tags := runGit(t, dir, "tag", "--list"); tags != "" {
+		t.Errorf("tags %v were not deleted", tags)
+	}
+}
+
+func TestReleasePushesBranchToUpstream(t *testing.T) {
+	dir := newGitPackage(t)
+	runGit(t, dir, "push", "-q", "-u", "origin", "HEAD:refs/heads/trunk")
+	g := newGitGoGetVers(t, dir)
+	g.ReleaseOptions.PushBranch = true
+	g.ReleaseOptions.CheckUpstream = true
+	if err := g.Release(filepath.Join(dir, "generated_gogetvers.go"), "proj", "0.2.1", "release 0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	head := runGit(t, dir, "rev-parse", "HEAD")
+	if pushed := runGit(t, dir, "ls-remote", "origin", "refs/heads/*"); !strings.HasPrefix(pushed, head+"\trefs/heads/trunk") || strings.Count(pushed, "\n") != 0 {
+		t.Errorf("origin has branches\n%v\nwant trunk at %v", pushed, head)
+	}
+}
+
+func TestReleaseRefusesUpstreamOnOtherRemote(t *testing.T) {
+	dir := newGitPackage(t)
+	other := filepath.Join(t.TempDir(), "other.git")
+	runGit(t, dir, "init", "-q", "--bare", other)
+	runGit(t, dir, "remote", "add", "other", other)
+	runGit(t, dir, "push", "-q", "-u", "other", "HEAD:refs/heads/trunk")
+	g := newGitGoGetVers(t, dir)
+	g.ReleaseOptions.PushBranch = true
+	err := g.Release(filepath.Join(dir, "generated_gogetvers.go"), "proj", "0.2.1", "release 0.2.1")
+	if err == nil || !strings.Contains(err.Error(), "on other, not origin") {
+		t.Fatalf("release with an upstream on other returned %v", err)
+	}
+	if tags := runGit(t, dir, "tag", "--list"); tags != "" {
+		t.Errorf("release created tags %v", tags)
+	}
+}
+
+func TestTagForceKeepsAnnotation(t *testing.T) {
+	dir := newGitPackage(t)
+	g := newGitGoGetVers(t, dir)
+	cmd := NewCommand("git", "tag", "-a", "-m", "the first release", "0.1.0")
+	cmd.Env = []string{"GIT_COMMITTER_NAME=Tagger", "GIT_COMMITTER_EMAIL=tagger@example.com", "GIT_COMMITTER_DATE=1500000000 +0200"}
+	if err := cmd.Exec(dir); err != nil {
+		t.Fatalf("%v: %v", err, cmd.ErrorOutput)
+	}
+	gofile := filepath.Join(dir, "generated_gogetvers.go")
+	if err := g.Tag(gofile, "proj", "0.1.0"); err == nil || !strings.Contains(err.Error(), "annotated") {
+		t.Fatalf("tag replaced an annotated tag without force: %v", err)
+	}
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "second")
+	g.TagOptions.Force = true
+	if err := g.Tag(gofile, "proj", "0.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	format := "--format=%(objecttype) %(taggername) %(taggeremail) %(taggerdate:raw) %(contents:subject)"
+	if got, want := runGit(t, dir, "for-each-ref", format, "refs/tags/0.1.0"), "tag Tagger <tagger@example.com> 1500000000 +0200 the first release"; got != want {
+		t.Errorf("forced tag is %q; want %q", got, want)
+	}
+	if tagged, head := runGit(t, dir, "rev-parse", "0.1.0^{commit}"), runGit(t, dir, "rev-parse", "HEAD"); tagged != head {
+		t.Errorf("forced tag is on %v; want %v", tagged, head)
+	}
+}
+
+func TestTagRefusesPublishedTag(t *testing.T) {
+	dir := newGitPackage(t)
+	g := newGitGoGetVers(t, dir)
+	runGit(t, dir, "tag", "0.1.0")
+	runGit(t, dir, "push", "-q", "origin", "0.1.0")
+	runGit(t, dir, "tag", "-d", "0.1.0")
+	gofile := filepath.Join(dir, "generated_gogetvers.go")
+	if err := g.Tag(gofile, "proj", "0.1.0"); err == nil || !strings.Contains(err.Error(), "exists on origin") {
+		t.Fatalf("tag replaced a published tag without force: %v", err)
+	}
+	g.TagOptions.Force = true
+	if err := g.Tag(gofile, "proj", "0.1.0"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTagUnreachableRemote(t *testing.T) {
+	dir := newGitPackage(t)
+	runGit(t, dir, "remote", "set-url", "origin", filepath.Join(t.TempDir(), "missing.git"))
+	g := newGitGoGetVers(t, dir)
+	gofile := filepath.Join(dir, "generated_gogetvers.go")
+	if err := g.Tag(gofile, "proj", "0.1.0"); err != nil {
+		t.Errorf("tag without push failed on an unreachable remote: %v", err)
+	}
+	g.TagOptions.Push = true
+	if err := g.Tag(gofile, "proj", "0.1.1"); err == nil || !strings.Contains(err.Error(), "can not check origin") {
+		t.Errorf("tag with push on an unreachable remote returned %v", err)
+	}
+}
+
+func TestReleasePostHookFailureSaysReleased(t *testing.T) {
+	dir := newGitPackage(t)
+	g := newGitGoGetVers(t, dir)
+	g.Hooks = &Hooks{Post: map[string][][]string{OperationRelease: {{"false"}}}}
+	err := g.Release(filepath.Join(dir, "generated_gogetvers.go"), "proj", "0.2.1", "release 0.2.1")
+	if err == nil || !strings.Contains(err.Error(), "release of 0.2.1 succeeded and it was pushed to origin") {
+		t.Fatalf("failing post hook returned %v", err)
+	}
+	if pushed := runGit(t, dir, "ls-remote", "origin", "refs/tags/0.2.1"); pushed == "" {
+		t.Error("release was rolled back")
+	}
+}
@@ -0,0 +1,56 @@
+package gogetvers
+
+import (
+	"time"
+)
+
+// Default wait before the first retry of a network command.
+const DefaultRetryDelay = time.Second
+
+// RetryPolicy describes how network commands (clone, fetch, push and
+// ls-remote) are retried when they fail.
+type RetryPolicy struct {
+	Attempts int           // Total attempts including the first; less than 1 means 1.
+	Delay    time.Duration // Wait before the first retry; doubled for each retry after.
+	MaxDelay time.Duration // Upper limit for the wait; zero means no limit.
+}
+
+// Returns the wait before retry number retry; the first retry is 1.
+func (rp *RetryPolicy) backoff(retry int) time.Duration {
+	rv := rp.Delay
+	for k := 1; k < retry; k++ {
+		rv = rv * 2
+		if rp.MaxDelay > 0 && rv >= rp.MaxDelay {
+			break
+		}
+	}
+	if rp.MaxDelay > 0 && rv > rp.MaxDelay {
+		rv = rp.MaxDelay
+	}
+	return rv
+}
+
+// A Runner that retries failing network commands according to a
+// RetryPolicy; other commands are run once.
+type retryRunner struct {
+	Runner Runner
+	Policy *RetryPolicy
+	Status *StatusWriter
+}
+
+// Satisfies Runner interface.
+func (r *retryRunner) Run(cmd *Command, chdir string) error {
+	runner := getRunner(r.Runner)
+	if cmd == nil || !cmd.Network || r.Policy == nil {
+		return runner.Run(cmd, chdir)
+	}
+	err := runner.Run(cmd, chdir)
+	for attempt := 2; err != nil && attempt <= r.Policy.Attempts; attempt++ {
+		wait := r.Policy.backoff(attempt - 1)
+		r.Status.Warning(err.Error())
+		r.Status.Printf("Retrying %v in %v (attempt %v of %v)\n", cmd.String(), wait, attempt, r.Policy.Attempts)
+		time.Sleep(wait)
+		err = runner.Run(cmd, chdir)
+	}
+	return err
+}
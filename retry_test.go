@@ -0,0 +1,53 @@
+package gogetvers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	tests := []struct {
+		policy RetryPolicy
+		want   []time.Duration // Waits before retries 1, 2, ...
+	}{
+		{RetryPolicy{Delay: time.Second}, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}},
+		{RetryPolicy{Delay: time.Second, MaxDelay: 3 * time.Second}, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}},
+		{RetryPolicy{Delay: 5 * time.Second, MaxDelay: 2 * time.Second}, []time.Duration{2 * time.Second, 2 * time.Second}},
+		{RetryPolicy{}, []time.Duration{0, 0}},
+	}
+	for _, test := range tests {
+		for k, want := range test.want {
+			if got := test.policy.backoff(k + 1); got != want {
+				t.Errorf("%+v retry %v waits %v; want %v", test.policy, k+1, got, want)
+			}
+		}
+	}
+}
+
+func TestRetryRunner(t *testing.T) {
+	tests := []struct {
+		cmd      *Command
+		attempts int
+		exitCode int
+		runs     int
+	}{
+		{NewCommandGitFetch("origin"), 3, 1, 3},
+		{NewCommandGitFetch("origin"), 3, 0, 1},
+		{NewCommandGitFetch("origin"), 0, 1, 1},
+		{NewCommandGitFetch("origin"), 1, 1, 1},
+		// Only network commands are retried.
+		{NewCommandGitTag("1.0.0"), 3, 1, 1},
+		{NewCommandGitStatus(), 3, 1, 1},
+	}
+	for _, test := range tests {
+		fake := NewFakeRunner().Script("", test.cmd.String(), "", test.exitCode)
+		runner := &retryRunner{Runner: fake, Policy: &RetryPolicy{Attempts: test.attempts, Delay: time.Nanosecond}}
+		err := runner.Run(test.cmd, "")
+		if (err != nil) != (test.exitCode != 0) {
+			t.Errorf("%v with exit code %v returned %v", test.cmd, test.exitCode, err)
+		}
+		if runs := len(fake.Commands()); runs != test.runs {
+			t.Errorf("%v with %v attempts and exit code %v ran %v times; want %v", test.cmd, test.attempts, test.exitCode, runs, test.runs)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package gogetvers
+
+// Runner executes commands on behalf of GoGetVers, Git and the package
+// analysis; implement it to replace how git and go are invoked.
+type Runner interface {
+	// Runs cmd with chdir as the working directory; chdir is ignored
+	// if it is an empty string.  Implementations set cmd.Output and
+	// cmd.ExitCode and return an error if the command fails.
+	Run(cmd *Command, chdir string) error
+}
+
+// The Runner used when one isn't otherwise provided.
+var DefaultRunner Runner = &ExecRunner{}
+
+// ExecRunner runs commands with os/exec via Command.Exec().
+type ExecRunner struct{}
+
+// Satisfies Runner interface.
+func (r *ExecRunner) Run(cmd *Command, chdir string) error {
+	return cmd.Exec(chdir)
+}
+
+// DirChecker is implemented by Runners that decide which directories
+// exist, such as FakeRunner; for other Runners the file system decides.
+type DirChecker interface {
+	// Returns true if path is a directory.
+	IsDir(path string) bool
+}
+
+// Returns true if path is a directory according to runner, or any
+// Runner it wraps, if it is a DirChecker; otherwise according to IsDir().
+func runnerIsDir(runner Runner, path string) bool {
+	for runner != nil {
+		if checker, ok := runner.(DirChecker); ok {
+			return checker.IsDir(path)
+		}
+		switch r := runner.(type) {
+		case *planRunner:
+			runner = r.Runner
+		case *retryRunner:
+			runner = r.Runner
+		case *toolchainRunner:
+			runner = r.Runner
+		case *TraceRunner:
+			runner = r.Runner
+		default:
+			runner = nil
+		}
+	}
+	return IsDir(path)
+}
+
+// Returns runner or DefaultRunner if runner is nil.
+func getRunner(runner Runner) Runner {
+	if runner == nil {
+		return DefaultRunner
+	}
+	return runner
+}
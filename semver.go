@@ -0,0 +1,189 @@
+package gogetvers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SemVer is a semantic version such as 1.2.3 or 1.2.3-rc.1.
+type SemVer struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string // Dot separated identifiers after the '-'; empty for a release.
+	Build      string // Dot separated identifiers after the '+'; ignored when comparing.
+}
+
+// Parses version as a semantic version; a leading 'v' is allowed and
+// missing minor or patch numbers are taken as 0.
+func ParseSemVer(version string) (*SemVer, error) {
+	rv := &SemVer{}
+	str := strings.TrimPrefix(version, "v")
+	if k := strings.Index(str, "+"); k >= 0 {
+		str, rv.Build = str[:k], str[k+1:]
+	}
+	if k := strings.Index(str, "-"); k >= 0 {
+		str, rv.Prerelease = str[:k], str[k+1:]
+		if rv.Prerelease == "" {
+			return nil, errors.New(fmt.Sprintf("empty prerelease in version %v", version))
+		}
+	}
+	pieces := strings.Split(str, ".")
+	if len(pieces) > 3 {
+		return nil, errors.New(fmt.Sprintf("not a semantic version: %v", version))
+	}
+	targets := []*int{&rv.Major, &rv.Minor, &rv.Patch}
+	for k, piece := range pieces {
+		num, err := strconv.Atoi(piece)
+		if err != nil || num < 0 || piece[0] == '+' {
+			return nil, errors.New(fmt.Sprintf("not a semantic version: %v", version))
+		}
+		*targets[k] = num
+	}
+	return rv, nil
+}
+
+// Returns the version as a string without a leading 'v'.
+func (v *SemVer) String() string {
+	if v == nil {
+		return ""
+	}
+	rv := fmt.Sprintf("%v.%v.%v", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		rv = rv + "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		rv = rv + "+" + v.Build
+	}
+	return rv
+}
+
+// Returns -1, 0 or 1 if v has lower, equal or higher precedence than other.
+func (v *SemVer) Compare(other *SemVer) int {
+	a, b := []int{v.Major, v.Minor, v.Patch}, []int{other.Major, other.Minor, other.Patch}
+	for k := range a {
+		if a[k] != b[k] {
+			return compareInts(a[k], b[k])
+		}
+	}
+	// A release has higher precedence than its prereleases.
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+	x, y := strings.Split(v.Prerelease, "."), strings.Split(other.Prerelease, ".")
+	for k := 0; k < len(x) && k < len(y); k++ {
+		if x[k] == y[k] {
+			continue
+		}
+		xn, xerr := strconv.Atoi(x[k])
+		yn, yerr := strconv.Atoi(y[k])
+		switch {
+		case xerr == nil && yerr == nil:
+			return compareInts(xn, yn)
+		case xerr == nil:
+			return -1 // Numeric identifiers are lower than alphanumeric.
+		case yerr == nil:
+			return 1
+		}
+		return strings.Compare(x[k], y[k])
+	}
+	return compareInts(len(x), len(y))
+}
+
+// Parts of a semantic version for SemVer.Bump.
+const (
+	BumpMajor      = "major"
+	BumpMinor      = "minor"
+	BumpPatch      = "patch"
+	BumpPrerelease = "prerelease"
+)
+
+// Returns the version after v when part, one of the Bump constants, is
+// incremented.  A prerelease is bumped to its release where that is the
+// next version of part, e.g. a patch bump of 1.2.3-rc.1 is 1.2.3.  A
+// prerelease bump increments the last numeric identifier of the
+// prerelease, e.g. 1.2.3-rc.1 to 1.2.3-rc.2, or starts the prereleases of
+// the next patch, e.g. 1.2.3 to 1.2.4-0.  Build metadata is dropped.
+func (v *SemVer) Bump(part string) (*SemVer, error) {
+	rv := &SemVer{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	switch part {
+	case BumpMajor:
+		if v.Prerelease == "" || v.Minor != 0 || v.Patch != 0 {
+			rv.Major, rv.Minor, rv.Patch = v.Major+1, 0, 0
+		}
+	case BumpMinor:
+		if v.Prerelease == "" || v.Patch != 0 {
+			rv.Minor, rv.Patch = v.Minor+1, 0
+		}
+	case BumpPatch:
+		if v.Prerelease == "" {
+			rv.Patch = v.Patch + 1
+		}
+	case BumpPrerelease:
+		if v.Prerelease == "" {
+			rv.Patch, rv.Prerelease = v.Patch+1, "0"
+			break
+		}
+		pieces := strings.Split(v.Prerelease, ".")
+		last := len(pieces) - 1
+		if num, err := strconv.Atoi(pieces[last]); err == nil {
+			pieces[last] = strconv.Itoa(num + 1)
+		} else {
+			pieces = append(pieces, "1")
+		}
+		rv.Prerelease = strings.Join(pieces, ".")
+	default:
+		return nil, errors.New(fmt.Sprintf("unknown version part: %v", part))
+	}
+	return rv, nil
+}
+
+// Describe is the output of 'git describe --tags --long --always' split
+// into its parts.
+type Describe struct {
+	Tag     string  // Most recent tag; empty if there is none.
+	Commits int     // Number of commits since Tag.
+	Hash    string  // Abbreviated commit hash.
+	SemVer  *SemVer // Tag as a semantic version; nil if it is not one.
+}
+
+// Parses the output of 'git describe --tags --long --always', e.g.
+// 1.2.0-0-g407583a3 or only an abbreviated hash if there are no tags.
+func ParseDescribe(describe string) (*Describe, error) {
+	if describe == "" {
+		return nil, errors.New("describe is empty")
+	}
+	rv := &Describe{}
+	pieces := strings.Split(describe, "-")
+	if len(pieces) < 3 || !strings.HasPrefix(pieces[len(pieces)-1], "g") {
+		// No tag; only the abbreviated hash.
+		rv.Hash = describe
+		return rv, nil
+	}
+	commits, err := strconv.Atoi(pieces[len(pieces)-2])
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("not a describe string: %v", describe))
+	}
+	rv.Tag = strings.Join(pieces[:len(pieces)-2], "-")
+	rv.Commits = commits
+	rv.Hash = strings.TrimPrefix(pieces[len(pieces)-1], "g")
+	rv.SemVer, _ = ParseSemVer(rv.Tag)
+	return rv, nil
+}
+
+// Returns -1, 0 or 1 if a is less than, equal to or greater than b.
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
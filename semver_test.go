@@ -0,0 +1,141 @@
+package gogetvers
+
+import (
+	"testing"
+)
+
+func TestParseSemVer(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string // SemVer.String(); empty if version is rejected.
+	}{
+		{"1.2.3", "1.2.3"},
+		{"v1.2.3", "1.2.3"},
+		{"1.2", "1.2.0"},
+		{"1", "1.0.0"},
+		{"1.2.3-rc.1", "1.2.3-rc.1"},
+		{"1.2.3+build.5", "1.2.3+build.5"},
+		{"1.2.3-rc.1+build.5", "1.2.3-rc.1+build.5"},
+		{"1.2.3-", ""},
+		{"1.2.3-+build", ""},
+		{"1.2.3.4", ""},
+		{"1.+2.3", ""},
+		{"1.-2.3", ""},
+		{"1.x.3", ""},
+		{"", ""},
+		{"release", ""},
+	}
+	for _, test := range tests {
+		got, err := ParseSemVer(test.version)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("ParseSemVer(%q) = %v; want an error", test.version, got)
+			}
+			continue
+		}
+		if err != nil || got.String() != test.want {
+			t.Errorf("ParseSemVer(%q) = %v, %v; want %v", test.version, got, err, test.want)
+		}
+	}
+}
+
+func TestSemVerCompare(t *testing.T) {
+	// Each version has higher precedence than the one before it.
+	ordered := []string{
+		"1.0.0-0",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"1.10.0",
+		"2.0.0",
+	}
+	for k := range ordered {
+		for j := range ordered {
+			a, _ := ParseSemVer(ordered[k])
+			b, _ := ParseSemVer(ordered[j])
+			if got, want := a.Compare(b), compareInts(k, j); got != want {
+				t.Errorf("%v.Compare(%v) = %v; want %v", ordered[k], ordered[j], got, want)
+			}
+		}
+	}
+	// Build metadata is ignored.
+	a, _ := ParseSemVer("1.0.0+a")
+	b, _ := ParseSemVer("v1.0.0+b")
+	if a.Compare(b) != 0 {
+		t.Errorf("%v and %v are not equal", a, b)
+	}
+}
+
+func TestParseDescribe(t *testing.T) {
+	tests := []struct {
+		describe string
+		tag      string
+		commits  int
+		hash     string
+		semver   string // Empty if the tag isn't a semantic version.
+		fails    bool
+	}{
+		{"1.2.0-0-g407583a3", "1.2.0", 0, "407583a3", "1.2.0", false},
+		{"v1.2.0-4-g407583a3", "v1.2.0", 4, "407583a3", "1.2.0", false},
+		{"1.2.0-rc.1-2-g407583a3", "1.2.0-rc.1", 2, "407583a3", "1.2.0-rc.1", false},
+		{"release-x-0-g407583a3", "release-x", 0, "407583a3", "", false},
+		{"407583a3", "", 0, "407583a3", "", false},
+		{"1.2.0-x-g407583a3", "", 0, "", "", true},
+		{"", "", 0, "", "", true},
+	}
+	for _, test := range tests {
+		got, err := ParseDescribe(test.describe)
+		if test.fails {
+			if err == nil {
+				t.Errorf("ParseDescribe(%q) = %+v; want an error", test.describe, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDescribe(%q) returns %v", test.describe, err)
+			continue
+		}
+		if got.Tag != test.tag || got.Commits != test.commits || got.Hash != test.hash || got.SemVer.String() != test.semver {
+			t.Errorf("ParseDescribe(%q) = %+v with version %v", test.describe, got, got.SemVer)
+		}
+	}
+}
+
+func TestSemVerBump(t *testing.T) {
+	tests := []struct {
+		version, part, want string
+	}{
+		{"1.2.3", BumpMajor, "2.0.0"},
+		{"1.2.3", BumpMinor, "1.3.0"},
+		{"1.2.3", BumpPatch, "1.2.4"},
+		{"1.2.3", BumpPrerelease, "1.2.4-0"},
+		{"2.0.0-rc.1", BumpMajor, "2.0.0"},
+		{"1.2.0-rc.1", BumpMajor, "2.0.0"},
+		{"1.3.0-rc.1", BumpMinor, "1.3.0"},
+		{"1.2.3-rc.1", BumpMinor, "1.3.0"},
+		{"1.2.3-rc.1", BumpPatch, "1.2.3"},
+		{"1.2.3-rc.1", BumpPrerelease, "1.2.3-rc.2"},
+		{"1.2.3-rc", BumpPrerelease, "1.2.3-rc.1"},
+		{"1.2.3+build.5", BumpPatch, "1.2.4"},
+	}
+	for _, test := range tests {
+		version, err := ParseSemVer(test.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := version.Bump(test.part)
+		if err != nil || got.String() != test.want {
+			t.Errorf("%v bump of %v = %v, %v; want %v", test.part, test.version, got, err, test.want)
+		}
+	}
+	if _, err := (&SemVer{}).Bump("build"); err == nil {
+		t.Error("unknown part is not an error")
+	}
+}
@@ -55,6 +55,24 @@ func (st *StatusWriter) Error(err error) {
 	st.Printf("ERROR: %v\n", err.Error())
 }
 
+// Writes the output and then the error output of cmd, if any, indented
+// one level.
+func (st *StatusWriter) WriteOutput(cmd *Command) {
+	if st == nil || cmd == nil {
+		return
+	}
+	st.Indent()
+	for _, output := range []string{cmd.Output, cmd.ErrorOutput} {
+		if output == "" {
+			continue
+		}
+		for _, line := range strings.Split(output, "\n") {
+			st.Writeln(line)
+		}
+	}
+	st.Outdent()
+}
+
 // Writes string with a WARNING prefix.
 func (st *StatusWriter) Warning(str string) {
 	st.Writeln("WARNING: " + str)
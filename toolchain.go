@@ -0,0 +1,40 @@
+package gogetvers
+
+// Toolchain describes the git and go binaries used for commands and
+// the extra environment they are run with.
+type Toolchain struct {
+	Git string   // The git executable; "git" from PATH if empty.
+	Go  string   // The go executable; "go" from PATH if empty.
+	Env []string // KEY=VALUE pairs added to the environment of every command.
+}
+
+// Returns the executable to run for bin.
+func (t *Toolchain) bin(bin string) string {
+	if bin == "git" && t.Git != "" {
+		return t.Git
+	}
+	if bin == "go" && t.Go != "" {
+		return t.Go
+	}
+	return bin
+}
+
+// A Runner that has the wrapped Runner run commands with a Toolchain.
+// The Toolchain is applied by Command.Exec() to a copy of each command
+// so callers, and other Runners, see the command as it was created.
+type toolchainRunner struct {
+	Runner    Runner
+	Toolchain *Toolchain
+}
+
+// Satisfies Runner interface.
+func (r *toolchainRunner) Run(cmd *Command, chdir string) error {
+	if cmd == nil || r.Toolchain == nil {
+		return getRunner(r.Runner).Run(cmd, chdir)
+	}
+	run := *cmd
+	run.toolchain = r.Toolchain
+	err := getRunner(r.Runner).Run(&run, chdir)
+	cmd.Output, cmd.ErrorOutput, cmd.ExitCode = run.Output, run.ErrorOutput, run.ExitCode
+	return err
+}
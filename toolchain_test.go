@@ -0,0 +1,37 @@
+package gogetvers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToolchainRunnerLeavesCommand(t *testing.T) {
+	fake := NewFakeRunner().Script("", "git status --porcelain", " M file.go", 0)
+	runner := &toolchainRunner{Runner: fake, Toolchain: &Toolchain{Git: "/opt/git/bin/git", Env: []string{"GIT_TRACE=0"}}}
+	cmd := NewCommandGitStatus()
+	for k := 0; k < 2; k++ {
+		if err := runner.Run(cmd, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cmd.Bin != "git" || len(cmd.Env) != 0 {
+		t.Errorf("command changed to %v with env %v", cmd.Bin, cmd.Env)
+	}
+	if cmd.Output != "M file.go" || cmd.ExitCode != 0 {
+		t.Errorf("output %q and exit code %v not copied back", cmd.Output, cmd.ExitCode)
+	}
+}
+
+func TestToolchainAppliedByExec(t *testing.T) {
+	if !IsFile("/usr/bin/env") {
+		t.Skip("no /usr/bin/env")
+	}
+	runner := &toolchainRunner{Runner: &ExecRunner{}, Toolchain: &Toolchain{Go: "/usr/bin/env", Env: []string{"GOGETVERS_TOOLCHAIN_TEST=yes"}}}
+	cmd := NewCommand("go")
+	if err := runner.Run(cmd, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(cmd.Output, "GOGETVERS_TOOLCHAIN_TEST=yes") {
+		t.Errorf("toolchain not applied; output is %v", cmd.Output)
+	}
+}
@@ -0,0 +1,66 @@
+package gogetvers
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Name of the environment variable that enables tracing to a file.
+const TraceEnvVar = "GOGETVERS_TRACE"
+
+// One line of the trace written by TraceRunner.
+type TraceEntry struct {
+	Bin        string        // The executable, as given by the Toolchain if any.
+	Args       []string      // Arguments to the executable.
+	Env        []string      `json:",omitempty"` // KEY=VALUE pairs added to the environment.
+	Dir        string        // Working directory.
+	Start      time.Time     // When the command started.
+	Duration   time.Duration // How long the command ran.
+	ExitCode   int           // Exit code; -1 if the command did not run.
+	StdoutSize int           // Size of standard output.
+	StderrSize int           // Size of standard error.
+	Error      string        // Error returned by the wrapped Runner, if any.
+}
+
+// TraceRunner passes commands to another Runner and writes a TraceEntry
+// for each of them to Writer as a line of JSON.
+type TraceRunner struct {
+	Runner Runner
+	Writer io.Writer
+	mutex  sync.Mutex
+}
+
+// Creates a TraceRunner that traces commands run by runner to writer.
+func NewTraceRunner(runner Runner, writer io.Writer) *TraceRunner {
+	return &TraceRunner{Runner: runner, Writer: writer}
+}
+
+// Satisfies Runner interface.
+func (r *TraceRunner) Run(cmd *Command, chdir string) error {
+	start := time.Now()
+	err := getRunner(r.Runner).Run(cmd, chdir)
+	if cmd == nil || r.Writer == nil {
+		return err
+	}
+	bin, env := cmd.resolve()
+	entry := TraceEntry{
+		Bin:        bin,
+		Args:       cmd.Args,
+		Env:        env,
+		Dir:        chdir,
+		Start:      start,
+		Duration:   time.Since(start),
+		ExitCode:   cmd.ExitCode,
+		StdoutSize: len(cmd.Output),
+		StderrSize: len(cmd.ErrorOutput)}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	// Tracing must never cause the command itself to fail.
+	json.NewEncoder(r.Writer).Encode(entry)
+	return err
+}
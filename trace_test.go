@@ -0,0 +1,68 @@
+package gogetvers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Returns the TraceEntry of each line written to buf.
+func readTrace(t *testing.T, buf *bytes.Buffer) []TraceEntry {
+	rv := []TraceEntry{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		entry := TraceEntry{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("%v: %v", line, err)
+		}
+		rv = append(rv, entry)
+	}
+	return rv
+}
+
+func TestTraceRunner(t *testing.T) {
+	fake := NewFakeRunner()
+	fake.ScriptResult("", "git status --porcelain", &FakeResult{Output: " M a.go", ErrorOutput: "warning", ExitCode: 0})
+	fake.Script("", "git fetch origin", "", 128)
+	fake.ScriptError("", "go version", errors.New("no go"))
+	buf := &bytes.Buffer{}
+	runner := NewTraceRunner(fake, buf)
+	for _, cmd := range []*Command{NewCommandGitStatus(), NewCommandGitFetch("origin"), NewCommandGoVersion()} {
+		runner.Run(cmd, "/work")
+	}
+	entries := readTrace(t, buf)
+	if len(entries) != 3 {
+		t.Fatalf("%v lines for 3 commands:\n%v", len(entries), buf.String())
+	}
+	tests := []struct {
+		bin                 string
+		exitCode, out, errs int
+		err                 bool
+	}{
+		{"git", 0, len("M a.go"), len("warning"), false},
+		{"git", 128, 0, 0, true},
+		{"go", -1, 0, 0, true},
+	}
+	for k, test := range tests {
+		entry := entries[k]
+		if entry.Bin != test.bin || entry.Dir != "/work" || entry.ExitCode != test.exitCode || entry.StdoutSize != test.out || entry.StderrSize != test.errs || (entry.Error != "") != test.err {
+			t.Errorf("entry %v is %+v", k, entry)
+		}
+	}
+}
+
+func TestTraceRunnerRecordsToolchain(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fake := NewFakeRunner().Script("", "git status --porcelain", "", 0)
+	runner := &toolchainRunner{Runner: NewTraceRunner(fake, buf), Toolchain: &Toolchain{Git: "/opt/git/bin/git", Env: []string{"GIT_TRACE=0"}}}
+	cmd := NewCommandGitStatus()
+	cmd.Env = []string{"LANG=C"}
+	if err := runner.Run(cmd, ""); err != nil {
+		t.Fatal(err)
+	}
+	entry := readTrace(t, buf)[0]
+	if entry.Bin != "/opt/git/bin/git" || strings.Join(entry.Env, " ") != "GIT_TRACE=0 LANG=C" {
+		t.Errorf("traced %v with %v", entry.Bin, entry.Env)
+	}
+}
@@ -0,0 +1,75 @@
+package gogetvers
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Options for the checks run before Release or Tag creates a tag.
+type VerifyOptions struct {
+	Release bool // If true then Release runs the checks.
+	Tag     bool // If true then Tag runs the checks.
+	// Commands run in the package directory instead of go build, go vet
+	// and go test, e.g. [["make", "check"]]; each is a binary and its
+	// arguments.
+	Commands [][]string
+}
+
+// Returns an error if any of the commands is empty.
+func (v *VerifyOptions) validate() error {
+	if v == nil {
+		return nil
+	}
+	for _, args := range v.Commands {
+		if len(args) == 0 {
+			return errors.New("empty verify command")
+		}
+	}
+	return nil
+}
+
+// Returns the commands Verify runs.
+func (g *GoGetVers) verifyCommands() []*Command {
+	rv := []*Command{}
+	if len(g.VerifyOptions.Commands) == 0 {
+		rv = append(rv, NewCommandGoBuild("."), NewCommandGoVet("."), NewCommandGoTest("."))
+		// Tests can write files.
+		for _, cmd := range rv {
+			cmd.Mutates = true
+		}
+	}
+	for _, args := range g.VerifyOptions.Commands {
+		rv = append(rv, NewCommandUser(args))
+	}
+	return rv
+}
+
+// Checks the package before it is tagged by running go build, go vet and
+// go test, or VerifyOptions.Commands if given, in Path.  The output of the
+// first command that fails is reported and an error is returned.
+func (g *GoGetVers) Verify() error {
+	if g == nil {
+		return errors.New("nil receiver")
+	}
+	err := g.VerifyOptions.validate()
+	if err != nil {
+		g.Status.Error(err)
+		return err
+	}
+	g.Status.Printf("Verifying package @ %v\n", g.Path)
+	g.Status.Indent()
+	defer g.Status.Outdent()
+	for _, cmd := range g.verifyCommands() {
+		g.Status.Writeln(cmd.String())
+		err = g.runner().Run(cmd, g.Path)
+		if err == nil {
+			continue
+		}
+		g.Status.WriteOutput(cmd)
+		err = errors.New(fmt.Sprintf("verification failed: %v", err))
+		g.Status.Error(err)
+		return err
+	}
+	g.Status.Writeln("done")
+	return nil
+}
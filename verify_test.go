@@ -0,0 +1,62 @@
+package gogetvers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyReportsFailingOutput(t *testing.T) {
+	g, fake := newFakeGoGetVers(t)
+	status := &bytes.Buffer{}
+	g.Status = &StatusWriter{Writer: status}
+	fake.Script(fakePackage, "go build -o "+os.DevNull+" .", "", 0)
+	fake.ScriptResult(fakePackage, "go vet .", &FakeResult{Output: "proj.go:3: bad", ErrorOutput: "exit status 1", ExitCode: 1})
+	if err := g.Verify(); err == nil {
+		t.Fatal("verify succeeded with a failing go vet")
+	}
+	if !strings.Contains(status.String(), "        proj.go:3: bad\n        exit status 1\n") {
+		t.Errorf("output of go vet not reported:\n%v", status.String())
+	}
+	for _, call := range fake.Commands() {
+		if call == "go test ." {
+			t.Error("go test ran after go vet failed")
+		}
+	}
+}
+
+func TestVerifyCommandsArePlanned(t *testing.T) {
+	g, fake := newFakeGoGetVers(t)
+	g.Plan = NewPlan()
+	g.VerifyOptions.Commands = [][]string{SplitQuoted("go test -run 'TestA|TestB' ./...")}
+	if err := g.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if calls := fake.Commands(); len(calls) != 0 {
+		t.Errorf("dry run ran %v", calls)
+	}
+	if plan := g.Plan.String(); !strings.Contains(plan, "go test -run TestA|TestB ./...") {
+		t.Errorf("plan does not have the command:\n%v", plan)
+	}
+}
+
+func TestVerifyRejectsEmptyCommands(t *testing.T) {
+	g, fake := newFakeGoGetVers(t)
+	g.VerifyOptions.Commands = [][]string{{"make", "check"}, {}}
+	if err := g.Verify(); err == nil {
+		t.Error("verify with an empty command succeeded")
+	}
+	if calls := len(fake.Commands()); calls != 0 {
+		t.Errorf("verify with an empty command ran %v commands", calls)
+	}
+	config := filepath.Join(t.TempDir(), ConfigFileName)
+	if err := ioutil.WriteFile(config, []byte(`{"Verify": {"Release": true, "Commands": [[]]}}`), 0664); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfigFile(config); err == nil || !strings.Contains(err.Error(), "empty verify command") {
+		t.Errorf("config with an empty verify command returned %v", err)
+	}
+}
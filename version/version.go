@@ -0,0 +1,65 @@
+// Package version holds version information injected at build time
+// with the -ldflags string printed by 'gogetvers ldflags', e.g.
+//
+//	go build -ldflags "$(gogetvers ldflags)"
+//
+// Variables that were not injected are empty strings.
+package version
+
+import (
+	"strings"
+)
+
+var (
+	Version      string // git describe of the package.
+	Hash         string // Commit hash of the package.
+	Branch       string // Branch of the package.
+	OriginUrl    string // Origin of the package.
+	Dirty        string // "true" if the package or any dependency had local modifications.
+	ManifestTime string // When the manifest was made in RFC3339 format.
+	Dependencies string // Comma separated name=version pairs for the git dependencies.
+)
+
+// Info satisfies the gogetvers Versioner interface with the package variables.
+type Info struct{}
+
+// Global variable for passing the package variables as a Versioner.
+var VersionInfo = Info{}
+
+// Returns the version for the package.
+func (vi Info) GetVersion(binaryName string) string {
+	return binaryName + " version " + Version
+}
+
+// Returns the version for the package and all of its dependencies.
+func (vi Info) GetVersionVerbose(binaryName string) string {
+	v := vi.GetVersion(binaryName)
+	deps := []string{}
+	for _, dep := range GetDependencies() {
+		deps = append(deps, dep[0]+" version "+dep[1])
+	}
+	if len(deps) > 0 {
+		v = v + "\n    " + strings.Join(deps, "\n    ")
+	}
+	return v
+}
+
+// Returns true if the package or any dependency had local modifications.
+func IsDirty() bool {
+	return Dirty == "true"
+}
+
+// Returns the dependencies as name and version pairs.
+func GetDependencies() [][2]string {
+	rv := [][2]string{}
+	if Dependencies == "" {
+		return rv
+	}
+	for _, dep := range strings.Split(Dependencies, ",") {
+		pieces := strings.SplitN(dep, "=", 2)
+		if len(pieces) == 2 {
+			rv = append(rv, [2]string{pieces[0], pieces[1]})
+		}
+	}
+	return rv
+}
@@ -1,40 +1,288 @@
 package gogetvers
 
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/build/constraint"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
 type Versioner interface {
 	GetVersion(binaryName string) string
 	GetVersionVerbose(binaryName string) string
 }
 
-const versionTemplate = `
-package $PACKAGE_NAME
+// The data passed to the template executed by Generate; the manifest
+// members are available directly, e.g. {{.Git.Hash}} or {{.DepsBuiltin}}.
+type TemplateData struct {
+	*PackageInfo
+	PackageName string  // Package name for the generated file.
+	VarName     string  // Name of the generated variable.
+	TypeName    string  // Name of the generated type.
+	Gits        GitList // Unique, sorted gits for the package and its dependencies.
+	Dirty       bool    // True if any of Gits has local modifications.
+	HTTP        bool    // True to make an http.Handler and publish with expvar.
+	// A //go:build line for the generated file; empty for none.
+	BuildConstraint string
+	// The compressed manifest to embed in the generated file; empty for none.
+	Manifest string
+	// The VersionData between markers for 'gogetvers inspect'.
+	VersionStamp string
+}
+
+// Functions available to templates executed by Generate.
+var templateFuncs = template.FuncMap{
+	"quote":     strconv.Quote,
+	"join":      strings.Join,
+	"describe":  templateDescribe,
+	"shquote":   shellQuote,
+	"makequote": makeQuote,
+	"cquote":    cQuote,
+	"upper":     upperSnakeCase,
+	"json":      jsonString,
+}
+
+// Parses describe for templates; returns an empty Describe if it can't be parsed.
+func templateDescribe(describe string) *Describe {
+	rv, err := ParseDescribe(describe)
+	if err != nil {
+		return &Describe{}
+	}
+	return rv
+}
+
+// Returns the name the generated variable is published as with expvar; it includes
+// the package directory so generated files in different packages don't collide.
+func (td *TemplateData) ExpvarName() string {
+	return filepath.ToSlash(td.PackageDir) + "." + td.VarName
+}
+
+// Creates the template data for the manifest p.
+func newTemplateData(p *PackageInfo, packageName string) *TemplateData {
+	rv := &TemplateData{
+		PackageInfo: p,
+		PackageName: packageName,
+		VarName:     "VersionInfo",
+		TypeName:    "VersionInfoType",
+		Gits:        p.getGits()}
+	for _, git := range rv.Gits {
+		rv.Dirty = rv.Dirty || git.Dirty()
+	}
+	return rv
+}
+
+// Applies the identifiers, build constraint and other settings in
+// options to the template data.
+func (td *TemplateData) applyOptions(options GenerateOptions) error {
+	if options.VarName != "" {
+		td.VarName = options.VarName
+		td.TypeName = options.VarName + "Type"
+	}
+	if options.TypeName != "" {
+		td.TypeName = options.TypeName
+	}
+	for _, name := range []string{td.VarName, td.TypeName} {
+		if !token.IsIdentifier(name) {
+			return errors.New(fmt.Sprintf("not a go identifier: %v", name))
+		}
+	}
+	if td.VarName == td.TypeName {
+		return errors.New(fmt.Sprintf("variable and type have the same name: %v", td.VarName))
+	}
+	if options.BuildConstraint != "" {
+		line := options.BuildConstraint
+		if !strings.HasPrefix(line, "//go:build") {
+			line = "//go:build " + line
+		}
+		_, err := constraint.Parse(line)
+		if err != nil {
+			return errors.New(fmt.Sprintf("invalid build constraint %v: %v", options.BuildConstraint, err.Error()))
+		}
+		td.BuildConstraint = line
+	}
+	td.HTTP = options.HTTP
+	vd, err := td.VersionData()
+	if err != nil {
+		return err
+	}
+	td.VersionStamp, err = encodeVersionData(vd)
+	if err != nil {
+		return err
+	}
+	if options.Embed {
+		td.Manifest, err = encodeManifest(td.PackageInfo)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Executes the template in templateFile with data; if templateFile is
+// empty then the built in template for format is used.
+func executeTemplate(templateFile, format string, data *TemplateData) ([]byte, error) {
+	text, err := formatTemplate(format)
+	if err != nil {
+		return nil, err
+	}
+	name := "gogetvers"
+	if templateFile != "" {
+		if !IsFile(templateFile) {
+			return nil, errors.New(fmt.Sprintf("Not a file @ %v", templateFile))
+		}
+		contents, err := ioutil.ReadFile(templateFile)
+		if err != nil {
+			return nil, err
+		}
+		name, text = filepath.Base(templateFile), string(contents)
+	}
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	err = tmpl.Execute(buf, data)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Formats the go source src with gofmt style and verifies the result
+// parses; filename is used for error messages.
+func formatGoSource(filename string, src []byte) ([]byte, error) {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid go source for %v: %v", filename, err.Error()))
+	}
+	_, err = parser.ParseFile(token.NewFileSet(), filename, formatted, parser.AllErrors)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid go source for %v: %v", filename, err.Error()))
+	}
+	return formatted, nil
+}
+
+const versionTemplate = `// Code generated by gogetvers; DO NOT EDIT.
+{{- if .BuildConstraint}}
+
+{{.BuildConstraint}}
+{{- end}}
+
+package {{.PackageName}}
 
 import(
+{{- if .Manifest}}
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+{{- end}}
+{{- if .HTTP}}
+	"encoding/json"
+	"expvar"
+	"net/http"
+{{- end}}
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Global variable containing version information from
 // gogetvers.
-var $VARNAME = $TYPE_NAME{$VERSION,[]struct{
-	Name string
-	Version string
-} $DEPENDENCIES}
+var {{.VarName}} = {{.TypeName}}{
+	Version: {{quote .Git.Describe}},
+	Hash: {{quote .Git.Hash}},
+	Branch: {{quote .Git.Branch}},
+	OriginUrl: {{quote .Git.OriginUrl}},
+	Status: {{quote .Git.Status}},
+	Dirty: {{.Dirty}},
+	ManifestTime: {{if .Created.IsZero}}time.Time{}{{else}}time.Unix({{.Created.Unix}}, 0).UTC(){{end}},
+	{{- if .Manifest}}
+	manifest: {{quote .Manifest}},
+	{{- end}}
+	{{- if .VersionStamp}}
+	stamp: {{quote .VersionStamp}},
+	{{- end}}
+	{{with .Git}}{{$.TypeName}}SemVer: {{$.TypeName}}SemVer{ {{- with .DescribeParts}}Tag: {{quote .Tag}}, CommitsSinceTag: {{.Commits}}, ShortHash: {{quote .Hash}},{{with .SemVer}} Semantic: true, Major: {{.Major}}, Minor: {{.Minor}}, Patch: {{.Patch}}, Prerelease: {{quote .Prerelease}},{{end}}{{end -}} },{{end}}
+	Dependencies: []{{.TypeName}}Dependency{
+{{- range .Gits}}
+		{
+			Name: {{quote .HomeDir}},
+			Version: {{quote .Describe}},
+			Hash: {{quote .Hash}},
+			Branch: {{quote .Branch}},
+			OriginUrl: {{quote .OriginUrl}},
+			Status: {{quote .Status}},
+			Dirty: {{.Dirty}},
+			{{$.TypeName}}SemVer: {{$.TypeName}}SemVer{ {{- with .DescribeParts}}Tag: {{quote .Tag}}, CommitsSinceTag: {{.Commits}}, ShortHash: {{quote .Hash}},{{with .SemVer}} Semantic: true, Major: {{.Major}}, Minor: {{.Minor}}, Patch: {{.Patch}}, Prerelease: {{quote .Prerelease}},{{end}}{{end -}} },
+		},
+{{- end}}
+	},
+}
+
+// {{.TypeName}} satisfies the gogetvers Versioner interface.
+var _ interface {
+	GetVersion(binaryName string) string
+	GetVersionVerbose(binaryName string) string
+} = {{.TypeName}}{}
 
 // Contains version information for package and its dependencies.
-type $TYPE_NAME struct {
-	Version string
-	Dependencies []struct{
-		Name string
-		Version string
-	}
+type {{.TypeName}} struct {
+	Version string // git describe of the package.
+	Hash string // Commit hash of the package.
+	Branch string // Branch of the package.
+	OriginUrl string // Origin of the package.
+	Status string // git status of the package; empty if no local modifications.
+	Dirty bool // True if the package or any dependency had local modifications.
+	ManifestTime time.Time // When the manifest was made; zero if unknown.
+	{{.TypeName}}SemVer // Version parsed into its parts.
+	Dependencies []{{.TypeName}}Dependency
+	{{- if .Manifest}}
+	manifest string // The compressed manifest; see Manifest().
+	{{- end}}
+	{{- if .VersionStamp}}
+	stamp string // The version information for 'gogetvers inspect'.
+	{{- end}}
+}
+
+// Contains version information for a single git dependency.
+type {{.TypeName}}Dependency struct {
+	Name string // Location of the git relative to the source root.
+	Version string // git describe of the dependency.
+	Hash string // Commit hash of the dependency.
+	Branch string // Branch of the dependency.
+	OriginUrl string // Origin of the dependency.
+	Status string // git status of the dependency; empty if no local modifications.
+	Dirty bool // True if the dependency had local modifications.
+	{{.TypeName}}SemVer // Version parsed into its parts.
+}
+
+// Contains a git describe string parsed into its parts.
+type {{.TypeName}}SemVer struct {
+	Tag string // Most recent tag; empty if there is none.
+	Semantic bool // True if Tag is a semantic version.
+	Major int
+	Minor int
+	Patch int
+	Prerelease string
+	CommitsSinceTag int // Number of commits since Tag.
+	ShortHash string // Abbreviated commit hash.
 }
 
 // Returns the version for the package.
-func (vt $TYPE_NAME) GetVersion(binaryName string) string {
+func (vt {{.TypeName}}) GetVersion(binaryName string) string {
 	return binaryName + " version " + vt.Version
 }
 
 // Returns the version for the package and all of its dependencies.
-func (vt $TYPE_NAME) GetVersionVerbose(binaryName string) string {
+func (vt {{.TypeName}}) GetVersionVerbose(binaryName string) string {
 	v := vt.GetVersion(binaryName)
 	deps := []string{}
 	for _,dep:=range vt.Dependencies {
@@ -45,4 +293,131 @@ func (vt $TYPE_NAME) GetVersionVerbose(binaryName string) string {
 	}
 	return v
 }
+
+{{- if .Manifest}}
+
+// Returns the manifest {{.VarName}} was generated from; it can be written to
+// a file for use with gogetvers checkout or rebuild.
+func (vt {{.TypeName}}) Manifest() ([]byte, error) {
+	encoded := strings.TrimSuffix(strings.TrimPrefix(vt.manifest, "` + ManifestMarkerBegin + `"), "` + ManifestMarkerEnd + `")
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	buf := &bytes.Buffer{}
+	_, err = buf.ReadFrom(zr)
+	return buf.Bytes(), err
+}
+{{- end}}
+{{- if .HTTP}}
+
+// Publishes {{.VarName}} with expvar unless the name is taken.
+func init() {
+	if expvar.Get({{quote .ExpvarName}}) == nil {
+		expvar.Publish({{quote .ExpvarName}}, expvar.Func(func() interface{} { return {{.VarName}} }))
+	}
+}
+
+// Serves the version information as JSON; {{.TypeName}} satisfies http.Handler
+// so {{.VarName}} can be mounted directly, e.g. http.Handle("/version", {{.VarName}}).
+func (vt {{.TypeName}}) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vt)
+}
+{{- end}}
+
+// Returns true if the version is at least version, e.g. AtLeast("1.2.0").
+func (sv {{.TypeName}}SemVer) AtLeast(version string) bool {
+	return sv.Compare(version) >= 0
+}
+
+// Returns -1, 0 or 1 if the version has lower, equal or higher precedence
+// than version; returns -1 if either is not a semantic version.
+func (sv {{.TypeName}}SemVer) Compare(version string) int {
+	other, ok := sv.parseSemVer(version)
+	if !ok || !sv.Semantic {
+		return -1
+	}
+	nums := [][2]int{ {sv.Major, other.Major}, {sv.Minor, other.Minor}, {sv.Patch, other.Patch} }
+	for _, num := range nums {
+		if num[0] != num[1] {
+			return sv.compareInts(num[0], num[1])
+		}
+	}
+	// A release has higher precedence than its prereleases.
+	switch {
+	case sv.Prerelease == other.Prerelease:
+		return 0
+	case sv.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+	x, y := strings.Split(sv.Prerelease, "."), strings.Split(other.Prerelease, ".")
+	for k := 0; k < len(x) && k < len(y); k++ {
+		if x[k] == y[k] {
+			continue
+		}
+		xn, xerr := strconv.Atoi(x[k])
+		yn, yerr := strconv.Atoi(y[k])
+		switch {
+		case xerr == nil && yerr == nil:
+			return sv.compareInts(xn, yn)
+		case xerr == nil:
+			return -1 // Numeric identifiers are lower than alphanumeric.
+		case yerr == nil:
+			return 1
+		}
+		return strings.Compare(x[k], y[k])
+	}
+	return sv.compareInts(len(x), len(y))
+}
+
+// Returns -1, 0 or 1 if a is less than, equal to or greater than b.
+func (sv {{.TypeName}}SemVer) compareInts(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+// Parses version; ok is false if it is not a semantic version.
+func (sv {{.TypeName}}SemVer) parseSemVer(version string) (rv {{.TypeName}}SemVer, ok bool) {
+	str := strings.TrimPrefix(version, "v")
+	if k := strings.Index(str, "+"); k >= 0 {
+		str = str[:k]
+	}
+	if k := strings.Index(str, "-"); k >= 0 {
+		str, rv.Prerelease = str[:k], str[k+1:]
+		if rv.Prerelease == "" {
+			return rv, false
+		}
+	}
+	pieces := strings.Split(str, ".")
+	if len(pieces) > 3 {
+		return rv, false
+	}
+	targets := []*int{&rv.Major, &rv.Minor, &rv.Patch}
+	for k, piece := range pieces {
+		num, err := strconv.Atoi(piece)
+		if err != nil || num < 0 || piece[0] == '+' {
+			return rv, false
+		}
+		*targets[k] = num
+	}
+	rv.Tag, rv.Semantic = version, true
+	return rv, true
+}
 `
@@ -0,0 +1,234 @@
+package gogetvers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateManifestTime(t *testing.T) {
+	info := NewPackageInfo("example.com/proj", "")
+	info.Git = &Git{HomeDir: "example.com/proj", Hash: fakeHash, Describe: "1.2.0-3-g0123abcd"}
+	source, err := executeTemplate("", FormatGo, newTemplateData(info, "proj"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = formatGoSource("generated_gogetvers.go", source); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(source), "ManifestTime: time.Time{},") {
+		t.Errorf("manifest without Created does not have a zero ManifestTime:\n%s", source)
+	}
+}
+
+// Returns the template data for the package main generated from info
+// with options.
+func generatedData(t *testing.T, info *PackageInfo, options GenerateOptions) *TemplateData {
+	rv := newTemplateData(info, "main")
+	if err := rv.applyOptions(options); err != nil {
+		t.Fatal(err)
+	}
+	return rv
+}
+
+// Writes the Go file generated from the template data of each name in
+// files and main.go with the source main to a temporary directory, builds
+// them and returns the path of the binary.
+func buildGenerated(t *testing.T, files map[string]*TemplateData, main string) string {
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	dir := t.TempDir()
+	sources := map[string][]byte{"main.go": []byte(main)}
+	for name, data := range files {
+		if sources[name], err = executeTemplate("", FormatGo, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	args := []string{"build", "-o", filepath.Join(dir, "proj")}
+	for name, source := range sources {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), source, 0664); err != nil {
+			t.Fatal(err)
+		}
+		args = append(args, name)
+	}
+	cmd := exec.Command(gobin, args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, output)
+	}
+	return filepath.Join(dir, "proj")
+}
+
+// The generated parseSemVer must accept exactly what ParseSemVer does.
+func TestGeneratedParseSemVerMatchesHost(t *testing.T) {
+	versions := []string{"1.2.3", "v1.2", "1.2.3-rc.1", "1.2.3+b", "1.2.3-", "1.2.3-+b", "1.+2.3", "1.2.3.4", "x"}
+	info := NewPackageInfo("example.com/proj", "")
+	info.Git = &Git{HomeDir: "example.com/proj", Hash: fakeHash, Describe: "1.2.0-3-g0123abcd"}
+	main := "package main\n\nimport \"fmt\"\n\nfunc valid(_ VersionInfoTypeSemVer, ok bool) bool { return ok }\n\nfunc main() {\n"
+	for _, version := range versions {
+		main = main + fmt.Sprintf("\tfmt.Println(valid(VersionInfo.parseSemVer(%q)))\n", version)
+	}
+	main = main + "}\n"
+	binary := buildGenerated(t, map[string]*TemplateData{"generated_gogetvers.go": newTemplateData(info, "main")}, main)
+	output, err := exec.Command(binary).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, output)
+	}
+	got := strings.Fields(string(output))
+	for k, version := range versions {
+		_, err := ParseSemVer(version)
+		if k >= len(got) || got[k] != fmt.Sprint(err == nil) {
+			t.Errorf("generated parseSemVer(%q) differs from ParseSemVer (%v)", version, err)
+		}
+	}
+}
+
+// The main.go for the generated variables vars: prints a JSON object with,
+// for each variable, the status code and body of a GET, the status code
+// and Allow header of a POST and whether it is published with expvar.
+func httpMain(vars map[string]string) string {
+	rv := "package main\n\nimport (\n\t\"encoding/json\"\n\t\"expvar\"\n\t\"net/http\"\n\t\"net/http/httptest\"\n\t\"os\"\n)\n\n"
+	rv = rv + "func serve(h http.Handler) map[string]interface{} {\n"
+	rv = rv + "\tget, post := httptest.NewRecorder(), httptest.NewRecorder()\n"
+	rv = rv + "\th.ServeHTTP(get, httptest.NewRequest(\"GET\", \"/version\", nil))\n"
+	rv = rv + "\th.ServeHTTP(post, httptest.NewRequest(\"POST\", \"/version\", nil))\n"
+	rv = rv + "\treturn map[string]interface{}{\"Get\": get.Code, \"Type\": get.Header().Get(\"Content-Type\"), \"Body\": get.Body.String(), \"Post\": post.Code, \"Allow\": post.Header().Get(\"Allow\")}\n}\n\n"
+	rv = rv + "func main() {\n\trv := map[string]interface{}{}\n"
+	for name, expvarName := range vars {
+		rv = rv + fmt.Sprintf("\trv[%q] = serve(%v)\n", name, name)
+		rv = rv + fmt.Sprintf("\trv[%q] = expvar.Get(%q) != nil\n", name+".expvar", expvarName)
+	}
+	rv = rv + "\tjson.NewEncoder(os.Stdout).Encode(rv)\n}\n"
+	return rv
+}
+
+// The result of serving a generated variable by the main from httpMain.
+type httpResult struct {
+	Get, Post   int
+	Type, Allow string
+	Body        string
+}
+
+// Runs binary, built with the main from httpMain, and returns the results
+// for each variable and whether it was published with expvar.
+func runHTTPMain(t *testing.T, binary string) (map[string]httpResult, map[string]bool) {
+	output, err := exec.Command(binary).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, output)
+	}
+	raw := map[string]json.RawMessage{}
+	if err = json.Unmarshal(output, &raw); err != nil {
+		t.Fatalf("%v\n%s", err, output)
+	}
+	results, published := map[string]httpResult{}, map[string]bool{}
+	for name, value := range raw {
+		if strings.HasSuffix(name, ".expvar") {
+			published[strings.TrimSuffix(name, ".expvar")] = string(value) == "true"
+			continue
+		}
+		result := httpResult{}
+		if err = json.Unmarshal(value, &result); err != nil {
+			t.Fatal(err)
+		}
+		results[name] = result
+	}
+	return results, published
+}
+
+func TestGeneratedServeHTTP(t *testing.T) {
+	info := NewPackageInfo("example.com/proj", "")
+	info.Git = &Git{HomeDir: "example.com/proj", Hash: fakeHash, Describe: "1.2.0-3-g0123abcd"}
+	data := generatedData(t, info, GenerateOptions{HTTP: true})
+	binary := buildGenerated(t, map[string]*TemplateData{"generated_gogetvers.go": data}, httpMain(map[string]string{"VersionInfo": data.ExpvarName()}))
+	results, published := runHTTPMain(t, binary)
+	result := results["VersionInfo"]
+	if result.Get != 200 || result.Type != "application/json" {
+		t.Errorf("GET returned %v with type %v", result.Get, result.Type)
+	}
+	body := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(result.Body), &body); err != nil || body["Version"] != "1.2.0-3-g0123abcd" || body["Hash"] != fakeHash {
+		t.Errorf("GET body %v, %v", result.Body, err)
+	}
+	if result.Post != 405 || result.Allow != "GET, HEAD" {
+		t.Errorf("POST returned %v with Allow %v", result.Post, result.Allow)
+	}
+	if !published["VersionInfo"] {
+		t.Errorf("VersionInfo is not published as %v", data.ExpvarName())
+	}
+}
+
+// Two generated files with their own variable and type names can be in
+// one package, each served and published separately.
+func TestGeneratedFilesCoexist(t *testing.T) {
+	info := NewPackageInfo("example.com/proj", "")
+	info.Git = &Git{HomeDir: "example.com/proj", Hash: fakeHash, Describe: "1.2.0-3-g0123abcd"}
+	data := generatedData(t, info, GenerateOptions{HTTP: true})
+	other := generatedData(t, info, GenerateOptions{HTTP: true, VarName: "BuildInfo", TypeName: "buildInfo"})
+	if other.TypeName != "buildInfo" || other.ExpvarName() == data.ExpvarName() {
+		t.Fatalf("type %v published as %v", other.TypeName, other.ExpvarName())
+	}
+	files := map[string]*TemplateData{"generated_gogetvers.go": data, "build_info.go": other}
+	binary := buildGenerated(t, files, httpMain(map[string]string{"VersionInfo": data.ExpvarName(), "BuildInfo": other.ExpvarName()}))
+	results, published := runHTTPMain(t, binary)
+	for _, name := range []string{"VersionInfo", "BuildInfo"} {
+		if results[name].Get != 200 || !published[name] {
+			t.Errorf("%v served %+v and published %v", name, results[name], published[name])
+		}
+	}
+}
+
+func TestApplyOptions(t *testing.T) {
+	info := NewPackageInfo("example.com/proj", "")
+	info.Git = &Git{HomeDir: "example.com/proj", Hash: fakeHash, Describe: "1.2.0-3-g0123abcd"}
+	tests := []struct {
+		options        GenerateOptions
+		varName, tname string // Empty if the options are rejected.
+	}{
+		{GenerateOptions{}, "VersionInfo", "VersionInfoType"},
+		{GenerateOptions{VarName: "BuildInfo"}, "BuildInfo", "BuildInfoType"},
+		{GenerateOptions{VarName: "BuildInfo", TypeName: "buildInfo"}, "BuildInfo", "buildInfo"},
+		{GenerateOptions{BuildConstraint: "linux && !race"}, "VersionInfo", "VersionInfoType"},
+		{GenerateOptions{VarName: "build-info"}, "", ""},
+		{GenerateOptions{TypeName: "1Type"}, "", ""},
+		{GenerateOptions{BuildConstraint: "linux &&"}, "", ""},
+		{GenerateOptions{BuildConstraint: "linux\npackage x"}, "", ""},
+	}
+	for _, test := range tests {
+		data := newTemplateData(info, "proj")
+		err := data.applyOptions(test.options)
+		if test.varName == "" {
+			if err == nil {
+				t.Errorf("%+v is not rejected", test.options)
+			}
+			continue
+		}
+		if err != nil || data.VarName != test.varName || data.TypeName != test.tname {
+			t.Errorf("%+v gives %v and %v, %v", test.options, data.VarName, data.TypeName, err)
+		}
+	}
+	data := newTemplateData(info, "proj")
+	if err := data.applyOptions(GenerateOptions{BuildConstraint: "linux && !race"}); err != nil || data.BuildConstraint != "//go:build linux && !race" {
+		t.Errorf("build constraint is %q, %v", data.BuildConstraint, err)
+	}
+}
+
+func TestGenerateRejectsInvalidBuild(t *testing.T) {
+	g, _ := newFakeGoGetVers(t)
+	if err := g.Make(); err != nil {
+		t.Fatal(err)
+	}
+	g.GenerateOptions.BuildConstraint = "linux &&"
+	gofile := filepath.Join(t.TempDir(), "generated_gogetvers.go")
+	if err := g.Generate(gofile, "proj"); err == nil || !strings.Contains(err.Error(), "invalid build constraint") {
+		t.Errorf("generate with an invalid build constraint returned %v", err)
+	}
+	if IsFile(gofile) {
+		t.Error("generate wrote a file")
+	}
+}